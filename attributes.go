@@ -1,60 +1,143 @@
 package gotell
 
 import (
+	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	"go.opentelemetry.io/otel/attribute"
 )
 
-// RequestAttributes populates
+// RequestAttributes derives observability attributes from a server request.
+//
+// The HTTP semconv stability mode selects whether the current, the deprecated
+// or both attribute sets are emitted. See WithHTTPSemconv.
+//
+// Headers are only captured if listed in the
+// OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_REQUEST variable.
 func RequestAttributes(req *http.Request) []attribute.KeyValue {
-	commonAttributes := commonRequestAttributes(req)
-	serverAttributes := serverRequestAttributes(req)
-	headerAttributes := requestHeaderAttributes(req)
+	return append(
+		requestAttributes(req),
+		RequestHeaderAttributes(req.Header, capturedHeadersFromEnv(CaptureServerRequestHeadersEnv)...)...,
+	)
+}
+
+func requestAttributes(req *http.Request) []attribute.KeyValue {
+	mode := httpSemconv.get()
+
+	attrs := make([]attribute.KeyValue, 0, 32)
+
+	if mode.emitsNew() {
+		commonAttributes := commonRequestAttributes(req)
+
+		attrs = append(attrs, commonAttributes[:]...)
+		attrs = append(attrs, serverRequestAttributes(req)...)
+
+		if parseMethod(req.Method) == methodOther {
+			attrs = append(attrs, attribute.String("http.request.method_original", req.Method))
+		}
+	}
+
+	if mode.emitsOld() {
+		attrs = append(attrs, oldRequestAttributes(req)...)
+	}
+
+	return attrs
+}
+
+// ClientRequestAttributes derives observability attributes from an outgoing
+// request. Contrary to RequestAttributes, it only includes the attributes that
+// identify the remote server, which are safe to use on metrics.
+func ClientRequestAttributes(req *http.Request) []attribute.KeyValue {
+	mode := httpSemconv.get()
+	serverAddress, serverPort := clientServerAddress(req)
 
-	attrs := make([]attribute.KeyValue, 0, len(commonAttributes)+len(serverAttributes)+len(headerAttributes))
+	attrs := make([]attribute.KeyValue, 0, 7)
 
-	attrs = append(attrs, commonAttributes[:]...)
-	attrs = append(attrs, serverAttributes...)
-	attrs = append(attrs, headerAttributes...)
+	if mode.emitsNew() {
+		attrs = append(attrs,
+			attribute.String("http.request.method", parseMethod(req.Method)),
+			attribute.String("server.address", serverAddress),
+			attribute.Int("server.port", serverPort),
+			attribute.String("url.scheme", req.URL.Scheme),
+		)
+	}
+
+	if mode.emitsOld() {
+		attrs = append(attrs,
+			attribute.String("http.method", parseMethod(req.Method)),
+			attribute.String("net.peer.name", serverAddress),
+			attribute.Int("net.peer.port", serverPort),
+		)
+	}
 
 	return attrs
 }
 
+// ResponseAttributes derives observability attributes from a client response.
+func ResponseAttributes(res *http.Response) []attribute.KeyValue {
+	return statusCodeAttributes(res.StatusCode)
+}
+
 // ResponseWriterAttributes derives observability attributes from a response
 // writer.
 //
 // It checks if the writer implements optional interfaces to further enrich the
-// attributes.
+// attributes. Headers are only captured if listed in the
+// OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_RESPONSE variable.
 func ResponseWriterAttributes(w http.ResponseWriter) []attribute.KeyValue {
-	attrs := make([]attribute.KeyValue, 0, 2+len(w.Header()))
+	return append(
+		ResponseHeaderAttributes(w.Header(), capturedHeadersFromEnv(CaptureServerResponseHeadersEnv)...),
+		responseWriterAttributes(w)...,
+	)
+}
 
-	for key, values := range w.Header() {
-		attrs = append(attrs, attribute.StringSlice("http.response."+strings.ToLower(key), values))
-	}
+func responseWriterAttributes(w http.ResponseWriter) []attribute.KeyValue {
+	mode := httpSemconv.get()
+	attrs := make([]attribute.KeyValue, 0, 4)
 
 	if ww, ok := w.(ResponseStatusReporter); ok {
-		attrs = append(attrs, attribute.Int("http.response.status_code", ww.Status()))
+		attrs = append(attrs, statusCodeAttributes(ww.Status())...)
 	}
 
 	if ww, ok := w.(ResponseContentLengthReporter); ok {
-		attrs = append(attrs, attribute.Int("http.response.body.size", ww.ContentLength()))
+		if mode.emitsNew() {
+			attrs = append(attrs, attribute.Int("http.response.body.size", ww.ContentLength()))
+		}
+
+		if mode.emitsOld() {
+			attrs = append(attrs, attribute.Int("http.response_content_length", ww.ContentLength()))
+		}
+	}
+
+	return attrs
+}
+
+func statusCodeAttributes(statusCode int) []attribute.KeyValue {
+	mode := httpSemconv.get()
+	attrs := make([]attribute.KeyValue, 0, 2)
+
+	if mode.emitsNew() {
+		attrs = append(attrs, attribute.Int("http.response.status_code", statusCode))
+	}
+
+	if mode.emitsOld() {
+		attrs = append(attrs, attribute.Int("http.status_code", statusCode))
 	}
 
 	return attrs
 }
 
-func commonRequestAttributes(req *http.Request) [15]attribute.KeyValue {
+func commonRequestAttributes(req *http.Request) [14]attribute.KeyValue {
 	protoName, protoVersion, _ := strings.Cut(strings.ToLower(req.Proto), "/")
-	serverAddress, serverPort, _ := strings.Cut(req.Host, ":")
+	serverAddress, serverPort := splitHostPort(req.Host)
 
 	return [...]attribute.KeyValue{
 		// HTTP
 		attribute.Int("http.request.body.size", int(req.ContentLength)),
 		attribute.String("http.request.method", parseMethod(req.Method)),
-		attribute.String("http.route", req.URL.Path),
 		// Network
 		attribute.String("network.protocol.name", protoName),
 		attribute.String("network.protocol.version", protoVersion),
@@ -74,22 +157,62 @@ func commonRequestAttributes(req *http.Request) [15]attribute.KeyValue {
 	}
 }
 
-func requestHeaderAttributes(req *http.Request) []attribute.KeyValue {
-	attrs := make([]attribute.KeyValue, 0, len(req.Header))
+// oldRequestAttributes returns the server request attributes as defined by
+// semantic conventions v1.20, before the HTTP conventions were stabilized.
+func oldRequestAttributes(req *http.Request) []attribute.KeyValue {
+	serverAddress, serverPort := splitHostPort(req.Host)
 
-	for key, values := range req.Header {
-		attrs = append(attrs, attribute.StringSlice("http.request."+strings.ToLower(key), values))
+	attrs := []attribute.KeyValue{
+		attribute.String("http.method", parseMethod(req.Method)),
+		attribute.String("http.scheme", req.URL.Scheme),
+		attribute.String("http.target", req.URL.RequestURI()),
+		attribute.String("net.host.name", serverAddress),
+		attribute.Int("net.host.port", parsePort(serverPort)),
+		attribute.Int("http.request_content_length", int(req.ContentLength)),
+		attribute.String("user_agent.original", req.UserAgent()),
+	}
+
+	if req.RemoteAddr != "" {
+		clientAddress, clientPort := splitHostPort(req.RemoteAddr)
+
+		attrs = append(attrs,
+			attribute.String("net.sock.peer.addr", clientAddress),
+			attribute.Int("net.sock.peer.port", parsePort(clientPort)),
+		)
 	}
 
 	return attrs
 }
 
+// clientServerAddress returns the remote server address and port of an
+// outgoing request, defaulting the port to the scheme one.
+func clientServerAddress(req *http.Request) (string, int) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	address, port := splitHostPort(host)
+	if port != "" {
+		return address, parsePort(port)
+	}
+
+	switch req.URL.Scheme {
+	case "https":
+		return address, 443
+	case "http":
+		return address, 80
+	default:
+		return address, 0
+	}
+}
+
 func serverRequestAttributes(req *http.Request) []attribute.KeyValue {
 	if req.RemoteAddr == "" {
 		return []attribute.KeyValue{}
 	}
 
-	clientAddress, clientPort, _ := strings.Cut(req.RemoteAddr, ":")
+	clientAddress, clientPort := splitHostPort(req.RemoteAddr)
 
 	return []attribute.KeyValue{
 		attribute.String("client.address", clientAddress),
@@ -97,6 +220,17 @@ func serverRequestAttributes(req *http.Request) []attribute.KeyValue {
 	}
 }
 
+// splitHostPort splits an address into host and port, which is optional. IPv6
+// hosts lose their brackets.
+func splitHostPort(hostport string) (string, string) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), ""
+	}
+
+	return host, port
+}
+
 func parsePort(value string) int {
 	port, err := strconv.ParseInt("0"+value, 10, 32)
 	if err != nil {
@@ -106,10 +240,34 @@ func parsePort(value string) int {
 	return int(port)
 }
 
+// methodOther replaces unknown request methods, so clients can't create new
+// time series with arbitrary methods.
+const methodOther = "_OTHER"
+
+//nolint:gochecknoglobals // read-only
+var knownMethods = []string{
+	http.MethodConnect,
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodTrace,
+}
+
+// parseMethod normalizes the request method, replacing unknown ones with
+// _OTHER as the semantic conventions require.
 func parseMethod(value string) string {
 	if value == "" {
 		return http.MethodGet
 	}
 
-	return strings.ToUpper(value)
+	value = strings.ToUpper(value)
+	if !slices.Contains(knownMethods, value) {
+		return methodOther
+	}
+
+	return value
 }
@@ -1,8 +1,10 @@
 package gotell_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -96,7 +98,6 @@ func TestResponseWriterAttributes(t *testing.T) {
 				statusCode: http.StatusNoContent,
 			},
 			want: []attribute.KeyValue{
-				attribute.StringSlice("http.response.x-foo", []string{"bar"}),
 				attribute.Int("http.response.status_code", http.StatusNoContent),
 				attribute.Int("http.response.body.size", 0),
 			},
@@ -125,3 +126,134 @@ func TestResponseWriterAttributes(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest // changes the global HTTP semconv mode
+func TestRequestAttributesSemconv(t *testing.T) {
+	t.Cleanup(func() { gotell.SetHTTPSemconv(gotell.SemconvFromEnv) })
+
+	newKeys := []attribute.KeyValue{
+		attribute.String("http.request.method", http.MethodGet),
+		attribute.String("server.address", "::1"),
+		attribute.Int("server.port", 8080),
+		attribute.String("client.address", "2001:db8::2"),
+		attribute.Int("client.port", 1234),
+	}
+
+	oldKeys := []attribute.KeyValue{
+		attribute.String("http.method", http.MethodGet),
+		attribute.String("net.host.name", "::1"),
+		attribute.Int("net.host.port", 8080),
+		attribute.String("net.sock.peer.addr", "2001:db8::2"),
+		attribute.Int("net.sock.peer.port", 1234),
+	}
+
+	tests := []struct {
+		name    string
+		mode    gotell.SemconvStability
+		want    []attribute.KeyValue
+		notWant []attribute.KeyValue
+	}{
+		{"old", gotell.SemconvOld, oldKeys, newKeys},
+		{"new", gotell.SemconvNew, newKeys, oldKeys},
+		{"duplicate", gotell.SemconvDuplicate, append(slices.Clone(oldKeys), newKeys...), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotell.SetHTTPSemconv(tt.mode)
+
+			req := httptest.NewRequest(http.MethodGet, "http://[::1]:8080/", nil)
+			req.RemoteAddr = "[2001:db8::2]:1234"
+
+			attrs := gotell.RequestAttributes(req)
+
+			assert.Subset(t, attrs, tt.want)
+
+			for _, attr := range tt.notWant {
+				assert.NotContains(t, attrs, attr)
+			}
+		})
+	}
+}
+
+func TestClientRequestAttributes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		target string
+		host   string
+		want   []attribute.KeyValue
+	}{
+		{
+			name:   "http default port",
+			target: "http://example.com/path?query=1",
+			host:   "",
+			want: []attribute.KeyValue{
+				attribute.String("http.request.method", http.MethodGet),
+				attribute.String("server.address", "example.com"),
+				attribute.Int("server.port", 80),
+				attribute.String("url.scheme", "http"),
+			},
+		},
+		{
+			name:   "https default port",
+			target: "https://example.com/",
+			host:   "",
+			want: []attribute.KeyValue{
+				attribute.String("http.request.method", http.MethodGet),
+				attribute.String("server.address", "example.com"),
+				attribute.Int("server.port", 443),
+				attribute.String("url.scheme", "https"),
+			},
+		},
+		{
+			name:   "ipv6",
+			target: "http://[2001:db8::1]:8080/",
+			host:   "",
+			want: []attribute.KeyValue{
+				attribute.String("http.request.method", http.MethodGet),
+				attribute.String("server.address", "2001:db8::1"),
+				attribute.Int("server.port", 8080),
+				attribute.String("url.scheme", "http"),
+			},
+		},
+		{
+			name:   "ipv6 default port",
+			target: "https://[2001:db8::1]/",
+			host:   "",
+			want: []attribute.KeyValue{
+				attribute.String("http.request.method", http.MethodGet),
+				attribute.String("server.address", "2001:db8::1"),
+				attribute.Int("server.port", 443),
+				attribute.String("url.scheme", "https"),
+			},
+		},
+		{
+			name:   "host override",
+			target: "http://10.0.0.1:8080/",
+			host:   "api.example.com",
+			want: []attribute.KeyValue{
+				attribute.String("http.request.method", http.MethodGet),
+				attribute.String("server.address", "api.example.com"),
+				attribute.Int("server.port", 80),
+				attribute.String("url.scheme", "http"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.target, nil)
+			require.NoError(t, err)
+
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+
+			assert.Equal(t, tt.want, gotell.ClientRequestAttributes(req))
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package gotell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// W3C Baggage limits, as enforced by the upstream parser.
+//
+// See https://www.w3.org/TR/baggage/#limits
+const (
+	MaxBaggageMembers     = 180
+	MaxBaggageMemberBytes = 4096
+	MaxBaggageBytes       = 8192
+
+	baggageHeader = "Baggage"
+)
+
+var (
+	// ErrBaggageNotFound happens when the context lacks a baggage member.
+	ErrBaggageNotFound = errors.New("baggage member not found")
+	// ErrBaggageTooLarge happens when a baggage exceeds the W3C limits.
+	ErrBaggageTooLarge = errors.New("baggage too large")
+	// ErrInvalidBaggage happens when a baggage member is malformed.
+	ErrInvalidBaggage = errors.New("invalid baggage")
+)
+
+var (
+	_ sdktrace.SpanProcessor = (*BaggageSpanProcessor)(nil)
+	_ sdklog.Processor       = (*BaggageLogProcessor)(nil)
+)
+
+// BaggageFilter reports whether a baggage member should become an attribute.
+type BaggageFilter func(member baggage.Member) bool
+
+// BaggageKeys allows baggage members with one of the exact keys.
+func BaggageKeys(keys ...string) BaggageFilter {
+	return func(member baggage.Member) bool {
+		return slices.Contains(keys, member.Key())
+	}
+}
+
+// BaggagePrefixes allows baggage members with a key that starts with any of the
+// prefixes.
+func BaggagePrefixes(prefixes ...string) BaggageFilter {
+	return func(member baggage.Member) bool {
+		return slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return strings.HasPrefix(member.Key(), prefix)
+		})
+	}
+}
+
+// BaggageAttributes converts the baggage members of the context allowed by the
+// filter into attributes. The member keys become the attribute keys as-is.
+func BaggageAttributes(ctx context.Context, filter BaggageFilter) []attribute.KeyValue {
+	members := baggage.FromContext(ctx).Members()
+
+	attrs := make([]attribute.KeyValue, 0, len(members))
+
+	for _, member := range members {
+		if filter(member) {
+			attrs = append(attrs, attribute.String(member.Key(), member.Value()))
+		}
+	}
+
+	return attrs
+}
+
+// BaggageSpanProcessor copies baggage members selected by a filter onto every
+// span as attributes when it starts.
+//
+// Use it with WithSpanProcessor.
+type BaggageSpanProcessor struct {
+	Filter BaggageFilter
+}
+
+// NewBaggageSpanProcessor creates a BaggageSpanProcessor.
+func NewBaggageSpanProcessor(filter BaggageFilter) *BaggageSpanProcessor {
+	return &BaggageSpanProcessor{Filter: filter}
+}
+
+// OnStart adds the selected baggage members to the span.
+func (processor *BaggageSpanProcessor) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	span.SetAttributes(BaggageAttributes(ctx, processor.Filter)...)
+}
+
+// OnEnd does nothing. It exists to satisfy the sdktrace.SpanProcessor
+// interface.
+func (*BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown does nothing. It exists to satisfy the sdktrace.SpanProcessor
+// interface.
+func (*BaggageSpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+// ForceFlush does nothing. It exists to satisfy the sdktrace.SpanProcessor
+// interface.
+func (*BaggageSpanProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// BaggageLogProcessor copies baggage members selected by a filter onto every
+// log record as attributes.
+//
+// Use it with WithLogProcessor, which runs it before the exporting processor.
+type BaggageLogProcessor struct {
+	Filter BaggageFilter
+}
+
+// NewBaggageLogProcessor creates a BaggageLogProcessor.
+func NewBaggageLogProcessor(filter BaggageFilter) *BaggageLogProcessor {
+	return &BaggageLogProcessor{Filter: filter}
+}
+
+// OnEmit adds the selected baggage members to the record.
+func (processor *BaggageLogProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	for _, member := range baggage.FromContext(ctx).Members() {
+		if processor.Filter(member) {
+			record.AddAttributes(log.String(member.Key(), member.Value()))
+		}
+	}
+
+	return nil
+}
+
+// Shutdown does nothing. It exists to satisfy the sdklog.Processor interface.
+func (*BaggageLogProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+// ForceFlush does nothing. It exists to satisfy the sdklog.Processor
+// interface.
+func (*BaggageLogProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// WithBaggageLabeler adds the baggage members selected by the filter to the
+// context labeler, so they become attributes of the HTTP server metrics.
+//
+// It must run within WithInstrumentationMiddleware, as the baggage is only
+// available after it extracts the request context:
+//
+//	WithInstrumentationMiddleware(WithBaggageLabeler(filter)(handler))
+//
+// It passes the request through unchanged if it already has a labeler, as the
+// instrumentation sets, so routers still report their pattern to it.
+func WithBaggageLabeler(filter BaggageFilter) MiddlewareFn {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, labeler := ContextLabeler(r.Context())
+
+			labeler.Add(BaggageAttributes(ctx, filter)...)
+
+			if ctx != r.Context() {
+				r = r.WithContext(ctx)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithBaggage returns a copy of the context with the baggage member set. The
+// value is stored raw and percent-encoded on propagation.
+//
+// It enforces the W3C Baggage limits enforced by the upstream parser on the
+// receiving side: MaxBaggageMemberBytes per member, MaxBaggageMembers members
+// and MaxBaggageBytes in total. The context is unchanged on error.
+func WithBaggage(
+	ctx context.Context,
+	key, value string,
+	properties ...baggage.Property,
+) (context.Context, error) {
+	member, err := baggage.NewMemberRaw(key, value, properties...)
+	if err != nil {
+		return ctx, errors.Join(ErrInvalidBaggage, err)
+	}
+
+	if len(member.String()) > MaxBaggageMemberBytes {
+		return ctx, fmt.Errorf("%w: member %q exceeds %d bytes", ErrBaggageTooLarge, key, MaxBaggageMemberBytes)
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, errors.Join(ErrInvalidBaggage, err)
+	}
+
+	if bag.Len() > MaxBaggageMembers {
+		return ctx, fmt.Errorf("%w: more than %d members", ErrBaggageTooLarge, MaxBaggageMembers)
+	}
+
+	if len(bag.String()) > MaxBaggageBytes {
+		return ctx, fmt.Errorf("%w: more than %d bytes", ErrBaggageTooLarge, MaxBaggageBytes)
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+// BaggageValue returns the value of a baggage member from the context, and
+// whether it exists.
+func BaggageValue(ctx context.Context, key string) (string, bool) {
+	member := baggage.FromContext(ctx).Member(key)
+
+	return member.Value(), member.Key() != ""
+}
+
+// BaggageInt64 parses a baggage member from the context as an integer.
+func BaggageInt64(ctx context.Context, key string) (int64, error) {
+	return parseBaggage(ctx, key, func(value string) (int64, error) {
+		return strconv.ParseInt(value, 10, 64)
+	})
+}
+
+// BaggageFloat64 parses a baggage member from the context as a float.
+func BaggageFloat64(ctx context.Context, key string) (float64, error) {
+	return parseBaggage(ctx, key, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+// BaggageBool parses a baggage member from the context as a boolean.
+func BaggageBool(ctx context.Context, key string) (bool, error) {
+	return parseBaggage(ctx, key, strconv.ParseBool)
+}
+
+// BaggagePolicy decides which inbound baggage members a request keeps.
+type BaggagePolicy func(req *http.Request, member baggage.Member) bool
+
+// AllowBaggage keeps the members selected by the filter, regardless of the
+// request.
+func AllowBaggage(filter BaggageFilter) BaggagePolicy {
+	return func(_ *http.Request, member baggage.Member) bool {
+		return filter(member)
+	}
+}
+
+// DenyBaggage drops all inbound baggage members.
+func DenyBaggage(*http.Request, baggage.Member) bool {
+	return false
+}
+
+// WithBaggagePolicyMiddleware drops the inbound baggage members the policy
+// rejects from the request headers. It also drops the whole baggage header if
+// it's malformed or exceeds the W3C Baggage limits.
+//
+// Prefer the WithBaggagePolicy instrumentation option. This middleware must run
+// before WithInstrumentationMiddleware extracts the request context:
+//
+//	WithBaggagePolicyMiddleware(policy)(WithInstrumentationMiddleware(handler))
+func WithBaggagePolicyMiddleware(policy BaggagePolicy) MiddlewareFn {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, filterBaggage(r, policy))
+		})
+	}
+}
+
+// filterBaggage returns a copy of the request without the baggage members the
+// policy rejects, or the request itself if it has no baggage.
+func filterBaggage(req *http.Request, policy BaggagePolicy) *http.Request {
+	values := req.Header.Values(baggageHeader)
+	if len(values) == 0 {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Del(baggageHeader)
+
+	bag, err := baggage.Parse(strings.Join(values, ","))
+	if err != nil {
+		return req
+	}
+
+	for _, member := range bag.Members() {
+		if !policy(req, member) {
+			bag = bag.DeleteMember(member.Key())
+		}
+	}
+
+	if bag.Len() > 0 {
+		req.Header.Set(baggageHeader, bag.String())
+	}
+
+	return req
+}
+
+func parseBaggage[T any](ctx context.Context, key string, parse func(string) (T, error)) (T, error) {
+	value, ok := BaggageValue(ctx, key)
+	if !ok {
+		var zero T
+
+		return zero, fmt.Errorf("%w: %s", ErrBaggageNotFound, key)
+	}
+
+	result, err := parse(value)
+	if err != nil {
+		return result, fmt.Errorf("%w: %s: %w", ErrInvalidBaggage, key, err)
+	}
+
+	return result, nil
+}
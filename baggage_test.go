@@ -0,0 +1,274 @@
+package gotell_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/wwmoraes/gotell"
+)
+
+// newBaggageContext returns a context with the baggage members, in the W3C
+// Baggage format.
+func newBaggageContext(t *testing.T, members string) context.Context {
+	t.Helper()
+
+	bag, err := baggage.Parse(members)
+	require.NoError(t, err)
+
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
+
+func TestWithBaggage(t *testing.T) {
+	t.Parallel()
+
+	ctx, err := gotell.WithBaggage(context.Background(), "tenant.id", "42")
+	require.NoError(t, err)
+
+	value, ok := gotell.BaggageValue(ctx, "tenant.id")
+	assert.True(t, ok)
+	assert.Equal(t, "42", value)
+
+	number, err := gotell.BaggageInt64(ctx, "tenant.id")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), number)
+
+	_, err = gotell.BaggageBool(ctx, "tenant.id")
+	require.ErrorIs(t, err, gotell.ErrInvalidBaggage)
+
+	_, err = gotell.BaggageFloat64(ctx, "missing")
+	require.ErrorIs(t, err, gotell.ErrBaggageNotFound)
+
+	_, err = gotell.WithBaggage(ctx, "large", strings.Repeat("x", gotell.MaxBaggageMemberBytes))
+	require.ErrorIs(t, err, gotell.ErrBaggageTooLarge)
+
+	_, err = gotell.WithBaggage(ctx, "", "value")
+	require.ErrorIs(t, err, gotell.ErrInvalidBaggage)
+}
+
+func TestWithBaggagePolicyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		policy gotell.BaggagePolicy
+		want   string
+	}{
+		{
+			name:   "allowed",
+			header: "tenant.id=42,user.id=7",
+			policy: gotell.AllowBaggage(gotell.BaggageKeys("tenant.id")),
+			want:   "tenant.id=42",
+		},
+		{
+			name:   "denied",
+			header: "tenant.id=42",
+			policy: gotell.DenyBaggage,
+			want:   "",
+		},
+		{
+			name:   "malformed",
+			header: "tenant.id",
+			policy: gotell.AllowBaggage(gotell.BaggagePrefixes("")),
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+
+			handler := gotell.WithBaggagePolicyMiddleware(tt.policy)(http.HandlerFunc(
+				func(_ http.ResponseWriter, r *http.Request) {
+					got = r.Header.Get("Baggage")
+				},
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Baggage", tt.header)
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithBaggagePolicy(t *testing.T) {
+	t.Parallel()
+
+	// routes apply their own policies on top of the mux ones
+	mux := gotell.NewServeMux(
+		gotell.WithTracerProvider(sdktrace.NewTracerProvider()),
+		gotell.WithTextMapPropagator(propagation.Baggage{}),
+		gotell.WithBaggagePolicy(gotell.AllowBaggage(gotell.BaggageKeys("tenant.id"))),
+	)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Baggage", r.Header.Get("Baggage"))
+		w.Header().Set("Context-Baggage", baggage.FromContext(r.Context()).String())
+	}
+
+	mux.HandleFunc("GET /internal", handler)
+	mux.HandleFunc("GET /public", handler, gotell.WithBaggagePolicy(gotell.DenyBaggage))
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/internal", "tenant.id=42"},
+		{"/public", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Baggage", "tenant.id=42,user.id=7")
+
+			res := httptest.NewRecorder()
+
+			mux.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.want, res.Header().Get("Baggage"))
+			assert.Equal(t, tt.want, res.Header().Get("Context-Baggage"))
+			assert.Equal(t, "tenant.id=42,user.id=7", req.Header.Get("Baggage"))
+		})
+	}
+}
+
+func TestBaggageAttributes(t *testing.T) {
+	t.Parallel()
+
+	ctx := newBaggageContext(t, "tenant.id=42,tenant.tier=gold,user.id=7")
+
+	tests := []struct {
+		name   string
+		filter gotell.BaggageFilter
+		want   []attribute.KeyValue
+	}{
+		{
+			name:   "keys",
+			filter: gotell.BaggageKeys("user.id", "missing"),
+			want:   []attribute.KeyValue{attribute.String("user.id", "7")},
+		},
+		{
+			name:   "prefixes",
+			filter: gotell.BaggagePrefixes("tenant."),
+			want: []attribute.KeyValue{
+				attribute.String("tenant.id", "42"),
+				attribute.String("tenant.tier", "gold"),
+			},
+		},
+		{
+			name:   "none",
+			filter: gotell.BaggageKeys(),
+			want:   []attribute.KeyValue{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ElementsMatch(t, tt.want, gotell.BaggageAttributes(ctx, tt.filter))
+		})
+	}
+}
+
+func TestBaggageSpanProcessor(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(gotell.NewBaggageSpanProcessor(gotell.BaggageKeys("tenant.id"))),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	_, span := provider.Tracer(t.Name()).Start(newBaggageContext(t, "tenant.id=42,user.id=7"), "test")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "42")}, spans[0].Attributes())
+}
+
+func TestBaggageLogProcessor(t *testing.T) {
+	t.Parallel()
+
+	processor := &recordingLogProcessor{mutex: sync.Mutex{}, records: nil}
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(gotell.NewBaggageLogProcessor(gotell.BaggageKeys("tenant.id"))),
+		sdklog.WithProcessor(processor),
+	)
+
+	var record log.Record
+
+	record.SetBody(log.StringValue("message"))
+	provider.Logger(t.Name()).Emit(newBaggageContext(t, "tenant.id=42,user.id=7"), record)
+
+	assert.Equal(t, map[string]log.Value{"tenant.id": log.StringValue("42")}, processor.last(t))
+}
+
+func TestWithBaggageLabeler(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	recorder := tracetest.NewSpanRecorder()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// the labeler sits between the instrumentation and the router, yet the
+	// route still reaches the instrumentation
+	handler := gotell.NewInstrumentationMiddleware(
+		gotell.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		gotell.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		gotell.WithTextMapPropagator(propagation.Baggage{}),
+	)(gotell.WithBaggageLabeler(gotell.BaggageKeys("tenant.id"))(mux))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("Baggage", "tenant.id=42,user.id=7")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /users/{id}", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/users/{id}"))
+
+	data := collectReaderMetric(t, reader, "http.server.request.duration")
+	require.NotNil(t, data)
+
+	histogram, ok := data.(metricdata.Histogram[float64])
+	require.True(t, ok, "unexpected data type %T", data)
+	require.Len(t, histogram.DataPoints, 1)
+
+	attrs := histogram.DataPoints[0].Attributes
+	assert.True(t, hasAttributes(attrs,
+		attribute.String("tenant.id", "42"),
+		attribute.String("http.route", "/users/{id}"),
+	))
+	assert.False(t, attrs.HasValue("user.id"))
+}
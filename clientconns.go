@@ -0,0 +1,200 @@
+package gotell
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	connectionStateKey    = "http.connection.state"
+	connectionStateActive = "active"
+	connectionStateIdle   = "idle"
+
+	defaultDialTimeout   = 30 * time.Second
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+// dialFn matches the dial functions of http.Transport.
+type dialFn func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// TrackConnections configures the transport to record the
+// http.client.open_connections and http.client.connection.duration metrics of
+// the connections it dials, then returns it. Call it before the transport is in
+// use, and use WithMetrics on top of it to tell active connections from idle
+// ones.
+//
+// Connections start idle, become active while they serve requests, as reported
+// by httptrace, and return to idle once the response bodies are done.
+//
+// It wraps the DialContext function, which disables HTTP/2 unless
+// ForceAttemptHTTP2 is set, so it sets it if the transport would use HTTP/2
+// otherwise. Connections from DialTLSContext aren't tracked, as the transport
+// needs their *tls.Conn as is.
+func TrackConnections(transport *http.Transport) *http.Transport {
+	transport.ForceAttemptHTTP2 = transport.ForceAttemptHTTP2 || attemptsHTTP2(transport)
+
+	dial := transport.DialContext
+	if dial == nil && transport.Dial != nil { //nolint:staticcheck // honor the deprecated field
+		dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+			return transport.Dial(network, addr) //nolint:staticcheck,wrapcheck // passthrough
+		}
+	}
+
+	if dial == nil {
+		//nolint:exhaustruct // same defaults as http.DefaultTransport
+		dial = (&net.Dialer{
+			Timeout:   defaultDialTimeout,
+			KeepAlive: defaultDialKeepAlive,
+		}).DialContext
+	}
+
+	transport.DialContext = trackedDial(dial)
+
+	return transport
+}
+
+// attemptsHTTP2 reports whether the transport uses HTTP/2 without forcing it,
+// mirroring the checks of net/http.
+func attemptsHTTP2(transport *http.Transport) bool {
+	return transport.TLSNextProto == nil &&
+		transport.TLSClientConfig == nil &&
+		transport.Dial == nil && //nolint:staticcheck // honor the deprecated field
+		transport.DialTLS == nil && //nolint:staticcheck // honor the deprecated field
+		transport.DialContext == nil &&
+		transport.DialTLSContext == nil
+}
+
+func trackedDial(dial dialFn) dialFn {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTrackedConn(ctx, conn, addr), nil
+	}
+}
+
+// trackedConn records the state changes and lifetime of a client connection.
+// HTTP/2 connections serve multiple requests at once, so it counts them.
+type trackedConn struct {
+	net.Conn
+
+	//nolint:containedctx // metrics need a context after the dial returns
+	ctx        context.Context
+	attributes []attribute.KeyValue
+	opened     time.Time
+
+	mu       sync.Mutex
+	inFlight int
+	closed   bool
+}
+
+func newTrackedConn(ctx context.Context, conn net.Conn, addr string) *trackedConn {
+	serverAddress, serverPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		serverAddress = addr
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("server.address", serverAddress),
+		attribute.Int("server.port", parsePort(serverPort)),
+	}
+
+	if remote, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		attrs = append(attrs, attribute.String("network.peer.address", remote.IP.String()))
+	}
+
+	tracked := &trackedConn{
+		Conn:       conn,
+		ctx:        context.WithoutCancel(ctx),
+		attributes: attrs,
+		opened:     time.Now(),
+		mu:         sync.Mutex{},
+		inFlight:   0,
+		closed:     false,
+	}
+
+	tracked.addOpen(connectionStateIdle, 1)
+
+	return tracked
+}
+
+// connectionOf returns the tracked connection behind the one httptrace reports,
+// which is a *tls.Conn for HTTPS.
+func connectionOf(conn net.Conn) (*trackedConn, bool) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	tracked, ok := conn.(*trackedConn)
+
+	return tracked, ok
+}
+
+// acquire marks a request as in-flight on the connection.
+func (conn *trackedConn) acquire() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if !conn.closed && conn.inFlight == 0 {
+		conn.addOpen(connectionStateIdle, -1)
+		conn.addOpen(connectionStateActive, 1)
+	}
+
+	conn.inFlight++
+}
+
+// release marks a request of the connection as done.
+func (conn *trackedConn) release() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	conn.inFlight--
+
+	if !conn.closed && conn.inFlight == 0 {
+		conn.addOpen(connectionStateActive, -1)
+		conn.addOpen(connectionStateIdle, 1)
+	}
+}
+
+// Close closes the connection and records its lifetime.
+func (conn *trackedConn) Close() error {
+	conn.mu.Lock()
+
+	if !conn.closed {
+		conn.closed = true
+
+		state := connectionStateIdle
+		if conn.inFlight > 0 {
+			state = connectionStateActive
+		}
+
+		conn.addOpen(state, -1)
+		httpClientConnectionDuration().Record(
+			conn.ctx,
+			time.Since(conn.opened).Seconds(),
+			metric.WithAttributes(conn.attributes...),
+		)
+	}
+
+	conn.mu.Unlock()
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return conn.Conn.Close()
+}
+
+func (conn *trackedConn) addOpen(state string, incr int64) {
+	httpClientOpenConnections().Add(conn.ctx, incr, metric.WithAttributes(slices.Concat(
+		conn.attributes,
+		[]attribute.KeyValue{attribute.String(connectionStateKey, state)},
+	)...))
+}
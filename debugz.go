@@ -0,0 +1,575 @@
+package gotell
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultSamplesPerBucket = 8
+	defaultLogRecords       = 256
+	maxTraceZSpanNames      = 1024
+	maxTraceZRunningSpans   = 4096
+)
+
+var (
+	_ sdktrace.SpanProcessor = (*TraceZProcessor)(nil)
+	_ sdklog.Processor       = (*LogZProcessor)(nil)
+	_ http.Handler           = (*TraceZProcessor)(nil)
+	_ http.Handler           = (*LogZProcessor)(nil)
+)
+
+// LatencyBucketBounds are the upper bounds of the TraceZProcessor latency
+// buckets. An extra bucket holds spans that last longer than the last bound.
+//
+//nolint:gochecknoglobals,mnd // same boundaries as OpenCensus zPages
+var LatencyBucketBounds = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	100 * time.Second,
+}
+
+// SpanSummary contains the span counters TraceZProcessor keeps per name.
+type SpanSummary struct {
+	Name    string   `json:"name"`
+	Running int      `json:"running"`
+	Latency []uint64 `json:"latency"`
+	Errors  uint64   `json:"errors"`
+}
+
+// SpanSample is a rendered span retained by TraceZProcessor.
+type SpanSample struct {
+	Name              string            `json:"name"`
+	Kind              string            `json:"kind"`
+	TraceID           string            `json:"traceId"`
+	SpanID            string            `json:"spanId"`
+	ParentSpanID      string            `json:"parentSpanId,omitempty"`
+	Start             time.Time         `json:"start"`
+	End               time.Time         `json:"end"`
+	DurationSeconds   float64           `json:"durationSeconds"`
+	StatusCode        string            `json:"statusCode"`
+	StatusDescription string            `json:"statusDescription,omitempty"`
+	Attributes        map[string]string `json:"attributes"`
+	Events            int               `json:"events"`
+}
+
+// LogSample is a rendered log record retained by LogZProcessor.
+type LogSample struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Severity   string            `json:"severity"`
+	Body       string            `json:"body"`
+	TraceID    string            `json:"traceId,omitempty"`
+	SpanID     string            `json:"spanId,omitempty"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// DebugHandler serves in-process telemetry viewers: /debug/tracez for spans
+// and /debug/logz for log records. Either processor may be nil.
+//
+// Register the processors with WithSpanProcessor and WithLogProcessor so they
+// receive data.
+func DebugHandler(tracez *TraceZProcessor, logz *LogZProcessor) http.Handler {
+	mux := http.NewServeMux()
+
+	if tracez != nil {
+		mux.Handle("GET /debug/tracez", tracez)
+	}
+
+	if logz != nil {
+		mux.Handle("GET /debug/logz", logz)
+	}
+
+	return mux
+}
+
+// TraceZProcessor is a span processor that retains recent, slow and errored
+// spans per span name in bounded buckets for in-process inspection, similar
+// to zPages.
+//
+// It serves the data through ServeHTTP. Without query parameters it renders a
+// summary with latency-bucket counters per span name. The following
+// parameters list samples instead:
+//
+//   - name: span name to list samples for
+//   - type: either recent (default), latency or error
+//   - bucket: latency bucket index, for the latency type
+//   - trace_id: lists only samples of this trace, across all names if no name is set
+//   - format: json renders JSON instead of HTML, as does an application/json Accept header
+type TraceZProcessor struct {
+	mutex            sync.Mutex
+	samplesPerBucket int
+	running          map[trace.SpanID]string
+	names            map[string]*traceZBucket
+}
+
+// NewTraceZProcessor creates a TraceZProcessor that keeps up to samplesPerBucket
+// spans per bucket. Non-positive values use a default of 8.
+func NewTraceZProcessor(samplesPerBucket int) *TraceZProcessor {
+	if samplesPerBucket <= 0 {
+		samplesPerBucket = defaultSamplesPerBucket
+	}
+
+	return &TraceZProcessor{
+		mutex:            sync.Mutex{},
+		samplesPerBucket: samplesPerBucket,
+		running:          map[trace.SpanID]string{},
+		names:            map[string]*traceZBucket{},
+	}
+}
+
+// OnStart tracks the span as running. It tracks up to 4096 spans at a time, so
+// spans that never end don't grow it forever; running counts stop increasing
+// past that.
+func (processor *TraceZProcessor) OnStart(_ context.Context, span sdktrace.ReadWriteSpan) {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	if len(processor.running) >= maxTraceZRunningSpans {
+		return
+	}
+
+	processor.running[span.SpanContext().SpanID()] = span.Name()
+}
+
+// OnEnd retains the span in its latency bucket and, if it failed, in the error
+// bucket of its name.
+func (processor *TraceZProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	delete(processor.running, span.SpanContext().SpanID())
+
+	bucket, ok := processor.names[span.Name()]
+	if !ok {
+		if len(processor.names) >= maxTraceZSpanNames {
+			return
+		}
+
+		bucket = newTraceZBucket(processor.samplesPerBucket)
+		processor.names[span.Name()] = bucket
+	}
+
+	bucket.add(span)
+}
+
+// Shutdown does nothing. It exists to satisfy the sdktrace.SpanProcessor
+// interface.
+func (*TraceZProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+// ForceFlush does nothing. It exists to satisfy the sdktrace.SpanProcessor
+// interface.
+func (*TraceZProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Summaries returns the counters of each span name, sorted by name.
+func (processor *TraceZProcessor) Summaries() []SpanSummary {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	running := make(map[string]int, len(processor.running))
+	for _, name := range processor.running {
+		running[name]++
+	}
+
+	summaries := make([]SpanSummary, 0, len(processor.names))
+
+	for name, bucket := range processor.names {
+		summaries = append(summaries, SpanSummary{
+			Name:    name,
+			Running: running[name],
+			Latency: slices.Clone(bucket.latencyCounts),
+			Errors:  bucket.errorCount,
+		})
+	}
+
+	slices.SortFunc(summaries, func(a, b SpanSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return summaries
+}
+
+// Samples returns the retained spans of a name and sample type, newest first.
+// The sample type is one of recent, latency or error; bucket is only used by
+// latency. An empty name returns samples of all names. A valid traceID filters
+// samples by trace.
+func (processor *TraceZProcessor) Samples(name, sampleType string, bucket int, traceID trace.TraceID) []SpanSample {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	var spans []sdktrace.ReadOnlySpan
+
+	for spanName, entry := range processor.names {
+		if name != "" && name != spanName {
+			continue
+		}
+
+		spans = append(spans, entry.samples(sampleType, bucket)...)
+	}
+
+	samples := make([]SpanSample, 0, len(spans))
+
+	for _, span := range spans {
+		if traceID.IsValid() && span.SpanContext().TraceID() != traceID {
+			continue
+		}
+
+		samples = append(samples, newSpanSample(span))
+	}
+
+	slices.SortFunc(samples, func(a, b SpanSample) int {
+		return b.End.Compare(a.End)
+	})
+
+	return samples
+}
+
+// ServeHTTP renders the summary or the samples selected by query parameters as
+// either HTML or JSON.
+func (processor *TraceZProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	traceID, _ := trace.TraceIDFromHex(query.Get("trace_id"))
+	name := query.Get("name")
+
+	if name == "" && !traceID.IsValid() {
+		renderDebugPage(w, r, traceZSummaryTemplate, map[string]any{
+			"bucketBounds": LatencyBucketBounds,
+			"summaries":    processor.Summaries(),
+		})
+
+		return
+	}
+
+	bucket, _ := strconv.Atoi(query.Get("bucket"))
+
+	renderDebugPage(w, r, traceZSamplesTemplate, map[string]any{
+		"samples": processor.Samples(name, query.Get("type"), bucket, traceID),
+	})
+}
+
+// LogZProcessor is a log processor that retains the most recent log records in
+// a ring buffer for in-process inspection.
+//
+// It serves the data through ServeHTTP, newest first. The trace_id query
+// parameter filters records by trace, and format=json or an application/json
+// Accept header renders JSON instead of HTML.
+type LogZProcessor struct {
+	mutex   sync.Mutex
+	records *ring[LogSample]
+}
+
+// NewLogZProcessor creates a LogZProcessor that keeps up to capacity records.
+// Non-positive values use a default of 256.
+func NewLogZProcessor(capacity int) *LogZProcessor {
+	if capacity <= 0 {
+		capacity = defaultLogRecords
+	}
+
+	return &LogZProcessor{
+		mutex:   sync.Mutex{},
+		records: newRing[LogSample](capacity),
+	}
+}
+
+// OnEmit retains a rendered copy of the record.
+func (processor *LogZProcessor) OnEmit(_ context.Context, record *sdklog.Record) error {
+	sample := newLogSample(record)
+
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	processor.records.push(sample)
+
+	return nil
+}
+
+// Shutdown does nothing. It exists to satisfy the sdklog.Processor interface.
+func (*LogZProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+// ForceFlush does nothing. It exists to satisfy the sdklog.Processor
+// interface.
+func (*LogZProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Records returns the retained records, newest first. A valid traceID filters
+// records by trace.
+func (processor *LogZProcessor) Records(traceID trace.TraceID) []LogSample {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	records := processor.records.items()
+
+	if traceID.IsValid() {
+		records = slices.DeleteFunc(records, func(sample LogSample) bool {
+			return sample.TraceID != traceID.String()
+		})
+	}
+
+	return records
+}
+
+// ServeHTTP renders the records as either HTML or JSON.
+func (processor *LogZProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	traceID, _ := trace.TraceIDFromHex(r.URL.Query().Get("trace_id"))
+
+	renderDebugPage(w, r, logZTemplate, map[string]any{
+		"records": processor.Records(traceID),
+	})
+}
+
+type traceZBucket struct {
+	latencyCounts []uint64
+	errorCount    uint64
+	latency       []*ring[sdktrace.ReadOnlySpan]
+	errors        *ring[sdktrace.ReadOnlySpan]
+	recent        *ring[sdktrace.ReadOnlySpan]
+}
+
+func newTraceZBucket(capacity int) *traceZBucket {
+	bucket := &traceZBucket{
+		latencyCounts: make([]uint64, len(LatencyBucketBounds)+1),
+		errorCount:    0,
+		latency:       make([]*ring[sdktrace.ReadOnlySpan], len(LatencyBucketBounds)+1),
+		errors:        newRing[sdktrace.ReadOnlySpan](capacity),
+		recent:        newRing[sdktrace.ReadOnlySpan](capacity),
+	}
+
+	for index := range bucket.latency {
+		bucket.latency[index] = newRing[sdktrace.ReadOnlySpan](capacity)
+	}
+
+	return bucket
+}
+
+func (bucket *traceZBucket) add(span sdktrace.ReadOnlySpan) {
+	index := latencyBucket(span.EndTime().Sub(span.StartTime()))
+
+	bucket.latencyCounts[index]++
+	bucket.latency[index].push(span)
+	bucket.recent.push(span)
+
+	if span.Status().Code == codes.Error {
+		bucket.errorCount++
+		bucket.errors.push(span)
+	}
+}
+
+func (bucket *traceZBucket) samples(sampleType string, index int) []sdktrace.ReadOnlySpan {
+	switch sampleType {
+	case "latency":
+		if index < 0 || index >= len(bucket.latency) {
+			return nil
+		}
+
+		return bucket.latency[index].items()
+	case "error":
+		return bucket.errors.items()
+	default:
+		return bucket.recent.items()
+	}
+}
+
+func latencyBucket(duration time.Duration) int {
+	for index, bound := range LatencyBucketBounds {
+		if duration < bound {
+			return index
+		}
+	}
+
+	return len(LatencyBucketBounds)
+}
+
+func newSpanSample(span sdktrace.ReadOnlySpan) SpanSample {
+	attributes := make(map[string]string, len(span.Attributes()))
+	for _, attr := range span.Attributes() {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	var parentSpanID string
+	if span.Parent().IsValid() {
+		parentSpanID = span.Parent().SpanID().String()
+	}
+
+	return SpanSample{
+		Name:              span.Name(),
+		Kind:              span.SpanKind().String(),
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		ParentSpanID:      parentSpanID,
+		Start:             span.StartTime(),
+		End:               span.EndTime(),
+		DurationSeconds:   span.EndTime().Sub(span.StartTime()).Seconds(),
+		StatusCode:        span.Status().Code.String(),
+		StatusDescription: span.Status().Description,
+		Attributes:        attributes,
+		Events:            len(span.Events()),
+	}
+}
+
+func newLogSample(record *sdklog.Record) LogSample {
+	attributes := make(map[string]string, record.AttributesLen())
+	record.WalkAttributes(func(attr log.KeyValue) bool {
+		attributes[attr.Key] = attr.Value.String()
+
+		return true
+	})
+
+	sample := LogSample{
+		Timestamp:  record.Timestamp(),
+		Severity:   record.SeverityText(),
+		Body:       record.Body().String(),
+		TraceID:    "",
+		SpanID:     "",
+		Attributes: attributes,
+	}
+
+	if sample.Timestamp.IsZero() {
+		sample.Timestamp = record.ObservedTimestamp()
+	}
+
+	if sample.Severity == "" {
+		sample.Severity = record.Severity().String()
+	}
+
+	if record.TraceID().IsValid() {
+		sample.TraceID = record.TraceID().String()
+	}
+
+	if record.SpanID().IsValid() {
+		sample.SpanID = record.SpanID().String()
+	}
+
+	return sample
+}
+
+// ring is a fixed-size circular buffer that overwrites its oldest items.
+type ring[T any] struct {
+	buffer []T
+	next   int
+	full   bool
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{
+		buffer: make([]T, capacity),
+		next:   0,
+		full:   false,
+	}
+}
+
+func (buffer *ring[T]) push(item T) {
+	buffer.buffer[buffer.next] = item
+	buffer.next = (buffer.next + 1) % len(buffer.buffer)
+	buffer.full = buffer.full || buffer.next == 0
+}
+
+// items returns a copy of the buffer contents, newest first.
+func (buffer *ring[T]) items() []T {
+	length := buffer.next
+	if buffer.full {
+		length = len(buffer.buffer)
+	}
+
+	items := make([]T, 0, length)
+
+	for offset := 1; offset <= length; offset++ {
+		items = append(items, buffer.buffer[(buffer.next-offset+len(buffer.buffer))%len(buffer.buffer)])
+	}
+
+	return items
+}
+
+func renderDebugPage(w http.ResponseWriter, r *http.Request, page *template.Template, data map[string]any) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(w).Encode(data)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := page.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//nolint:gochecknoglobals // parsed once
+var (
+	traceZSummaryTemplate = template.Must(template.New("tracez").Parse(`<!DOCTYPE html>
+<html><head><title>tracez</title></head><body>
+<h1>tracez</h1>
+<table border="1">
+<tr><th>name</th><th>running</th>{{range $index, $bound := .bucketBounds}}<th>&lt;{{$bound}}</th>{{end}}<th>&ge;last</th><th>errors</th></tr>
+{{range .summaries}}{{$name := .Name}}<tr>
+<td><a href="?name={{.Name}}">{{.Name}}</a></td>
+<td>{{.Running}}</td>
+{{range $index, $count := .Latency}}<td><a href="?name={{$name}}&type=latency&bucket={{$index}}">{{$count}}</a></td>{{end}}
+<td><a href="?name={{.Name}}&type=error">{{.Errors}}</a></td>
+</tr>{{end}}
+</table>
+</body></html>`))
+
+	traceZSamplesTemplate = template.Must(template.New("tracez-samples").Parse(`<!DOCTYPE html>
+<html><head><title>tracez</title></head><body>
+<h1><a href="?">tracez</a></h1>
+<table border="1">
+<tr><th>end</th><th>name</th><th>trace</th><th>span</th><th>parent</th><th>duration (s)</th><th>status</th><th>attributes</th></tr>
+{{range .samples}}<tr>
+<td>{{.End.Format "2006-01-02T15:04:05.000000Z07:00"}}</td>
+<td>{{.Name}} ({{.Kind}})</td>
+<td><a href="?trace_id={{.TraceID}}">{{.TraceID}}</a></td>
+<td>{{.SpanID}}</td>
+<td>{{.ParentSpanID}}</td>
+<td>{{printf "%.6f" .DurationSeconds}}</td>
+<td>{{.StatusCode}} {{.StatusDescription}}</td>
+<td>{{range $key, $value := .Attributes}}{{$key}}={{$value}}<br>{{end}}</td>
+</tr>{{end}}
+</table>
+</body></html>`))
+
+	logZTemplate = template.Must(template.New("logz").Parse(`<!DOCTYPE html>
+<html><head><title>logz</title></head><body>
+<h1><a href="?">logz</a></h1>
+<table border="1">
+<tr><th>timestamp</th><th>severity</th><th>body</th><th>trace</th><th>span</th><th>attributes</th></tr>
+{{range .records}}<tr>
+<td>{{.Timestamp.Format "2006-01-02T15:04:05.000000Z07:00"}}</td>
+<td>{{.Severity}}</td>
+<td>{{.Body}}</td>
+<td><a href="?trace_id={{.TraceID}}">{{.TraceID}}</a></td>
+<td>{{.SpanID}}</td>
+<td>{{range $key, $value := .Attributes}}{{$key}}={{$value}}<br>{{end}}</td>
+</tr>{{end}}
+</table>
+</body></html>`))
+)
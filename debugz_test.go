@@ -0,0 +1,168 @@
+package gotell_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestTraceZProcessor(t *testing.T) {
+	t.Parallel()
+
+	tracez := gotell.NewTraceZProcessor(2)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracez)).Tracer(t.Name())
+
+	ctx, running := tracer.Start(context.Background(), "running")
+
+	for range 3 {
+		_, span := tracer.Start(ctx, "done")
+		span.End()
+	}
+
+	_, failed := tracer.Start(ctx, "done")
+	failed.SetStatus(codes.Error, "failed")
+	failed.End()
+
+	summaries := tracez.Summaries()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "done", summaries[0].Name)
+	assert.Equal(t, uint64(1), summaries[0].Errors)
+
+	running.End()
+
+	samples := tracez.Samples("done", "recent", 0, trace.TraceID{})
+	assert.Len(t, samples, 2)
+
+	samples = tracez.Samples("", "error", 0, running.SpanContext().TraceID())
+	require.Len(t, samples, 1)
+	assert.Equal(t, failed.SpanContext().SpanID().String(), samples[0].SpanID)
+
+	recorder := httptest.NewRecorder()
+	gotell.DebugHandler(tracez, nil).ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodGet, "/debug/tracez?format=json", nil),
+	)
+
+	var body struct {
+		Summaries []gotell.SpanSummary `json:"summaries"`
+	}
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Len(t, body.Summaries, 2)
+}
+
+func TestTraceZProcessorRunningLimit(t *testing.T) {
+	t.Parallel()
+
+	tracez := gotell.NewTraceZProcessor(1)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracez)).Tracer(t.Name())
+
+	// spans only have a summary once one of their name ends
+	_, span := tracer.Start(context.Background(), "open")
+	span.End()
+
+	// spans that never end
+	for range gotell.MaxTraceZRunningSpans + 1 {
+		_, _ = tracer.Start(context.Background(), "open")
+	}
+
+	summaries := tracez.Summaries()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, gotell.MaxTraceZRunningSpans, summaries[0].Running)
+}
+
+func TestLogZProcessor(t *testing.T) {
+	t.Parallel()
+
+	logz := gotell.NewLogZProcessor(2)
+	logger := sdklog.NewLoggerProvider(sdklog.WithProcessor(logz)).Logger(t.Name())
+
+	traceID := trace.TraceID{1}
+
+	//nolint:exhaustruct // only the identifiers matter
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1},
+	}))
+
+	emit := func(ctx context.Context, body string) {
+		var record log.Record
+
+		record.SetBody(log.StringValue(body))
+		record.SetSeverity(log.SeverityInfo)
+		record.AddAttributes(log.String("key", body))
+
+		logger.Emit(ctx, record)
+	}
+
+	// the oldest falls out of the ring buffer
+	emit(context.Background(), "first")
+	emit(traced, "second")
+	emit(context.Background(), "third")
+
+	records := logz.Records(trace.TraceID{})
+	require.Len(t, records, 2)
+	assert.Equal(t, "third", records[0].Body)
+	assert.Equal(t, "second", records[1].Body)
+	assert.Equal(t, "INFO", records[1].Severity)
+	assert.Equal(t, map[string]string{"key": "second"}, records[1].Attributes)
+
+	records = logz.Records(traceID)
+	require.Len(t, records, 1)
+	assert.Equal(t, "second", records[0].Body)
+	assert.Equal(t, traceID.String(), records[0].TraceID)
+
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		wantContentType string
+	}{
+		{"html", "", "", "text/html; charset=utf-8"},
+		{"json format", "format=json&", "", "application/json"},
+		{"json accept", "", "application/json", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/debug/logz?"+tt.query+"trace_id="+traceID.String(), nil)
+			req.Header.Set("Accept", tt.accept)
+
+			recorder := httptest.NewRecorder()
+			gotell.DebugHandler(nil, logz).ServeHTTP(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.wantContentType, recorder.Header().Get("Content-Type"))
+
+			if tt.wantContentType != "application/json" {
+				assert.Contains(t, recorder.Body.String(), "<td>second</td>")
+				assert.NotContains(t, recorder.Body.String(), "<td>third</td>")
+
+				return
+			}
+
+			var body struct {
+				Records []gotell.LogSample `json:"records"`
+			}
+
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+			require.Len(t, body.Records, 1)
+			assert.Equal(t, "second", body.Records[0].Body)
+		})
+	}
+}
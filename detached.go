@@ -0,0 +1,76 @@
+package gotell
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queueDurationKey is the time in seconds a detached span waited between
+// Detach and StartDetached.
+const queueDurationKey = attribute.Key("gotell.queue.duration")
+
+type detachedContextKey struct{}
+
+type detachedOrigin struct {
+	spanContext trace.SpanContext
+	scheduled   time.Time
+}
+
+// Detach prepares a context for work that outlives the current one, such as
+// asynchronous jobs. It's not canceled with the original context, and records
+// the current span and time for StartDetached to link and measure the queueing
+// delay.
+//
+// Call it when scheduling the work, then StartDetached when it executes.
+func Detach(ctx context.Context) context.Context {
+	return context.WithValue(context.WithoutCancel(ctx), detachedContextKey{}, detachedOrigin{
+		spanContext: trace.SpanContextFromContext(ctx),
+		scheduled:   time.Now(),
+	})
+}
+
+// StartDetached creates a new root span with a link to the span of the context
+// instead of a child of it. It suits work started from a request that
+// outlives it. The returned context isn't canceled with the original one, and
+// keeps its values, including the baggage.
+//
+// If the context comes from Detach, the link points to the span at that time
+// and the span records the queueing delay in seconds as gotell.queue.duration.
+//
+//nolint:ireturn // same practice as upstream to protect internal data
+func StartDetached(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, Span) {
+	origin, ok := ctx.Value(detachedContextKey{}).(detachedOrigin)
+	if !ok {
+		origin = detachedOrigin{
+			spanContext: trace.SpanContextFromContext(ctx),
+			scheduled:   time.Time{},
+		}
+	}
+
+	tracer := Tracer(ctx)
+
+	// drop the current span so it doesn't become the parent, and the origin so
+	// nested detached spans link to this one instead
+	ctx = trace.ContextWithSpanContext(context.WithoutCancel(ctx), trace.SpanContext{})
+	ctx = context.WithValue(ctx, detachedContextKey{}, nil)
+
+	opts = append(opts, trace.WithNewRoot())
+
+	if origin.spanContext.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{
+			SpanContext: origin.spanContext,
+			Attributes:  nil,
+		}))
+	}
+
+	if !origin.scheduled.IsZero() {
+		opts = append(opts, trace.WithAttributes(
+			queueDurationKey.Float64(time.Since(origin.scheduled).Seconds()),
+		))
+	}
+
+	return tracerStart(ctx, tracer, spanName, opts...)
+}
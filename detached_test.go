@@ -0,0 +1,108 @@
+package gotell_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wwmoraes/gotell"
+)
+
+const queueDurationKey = attribute.Key("gotell.queue.duration")
+
+func TestDetach(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+	parent := trace.SpanContextFromContext(ctx)
+
+	member, err := baggage.NewMember("tenant", "acme")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(baggage.ContextWithBaggage(ctx, bag))
+
+	detached := gotell.Detach(ctx)
+
+	cancel()
+	require.Error(t, ctx.Err())
+	require.NoError(t, detached.Err())
+
+	time.Sleep(10 * time.Millisecond)
+
+	jobCtx, span := gotell.StartDetached(detached, "job")
+	span.End()
+
+	require.NoError(t, jobCtx.Err())
+	assert.Equal(t, "acme", baggage.FromContext(jobCtx).Member("tenant").Value())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	// a new root linked to the span that scheduled the job
+	assert.Equal(t, "job", spans[0].Name())
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.NotEqual(t, parent.TraceID(), spans[0].SpanContext().TraceID())
+	require.Len(t, spans[0].Links(), 1)
+	assert.True(t, spans[0].Links()[0].SpanContext.Equal(parent))
+
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+
+	queueDuration, ok := attrs.Value(queueDurationKey)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, queueDuration.AsFloat64(), (10 * time.Millisecond).Seconds())
+}
+
+func TestStartDetached(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+	parent := trace.SpanContextFromContext(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	jobCtx, span := gotell.StartDetached(ctx, "job")
+	span.End()
+
+	require.NoError(t, jobCtx.Err())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.False(t, spans[0].Parent().IsValid())
+	require.Len(t, spans[0].Links(), 1)
+	assert.True(t, spans[0].Links()[0].SpanContext.Equal(parent))
+
+	// without Detach there's no scheduling time to measure from
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	assert.False(t, attrs.HasValue(queueDurationKey))
+}
+
+func TestStartDetachedNested(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	jobCtx, job := gotell.StartDetached(gotell.Detach(ctx), "job")
+
+	// the nested job links to the first one, not to its origin
+	_, nested := gotell.StartDetached(gotell.Detach(jobCtx), "nested")
+	nested.End()
+	job.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "nested", spans[0].Name())
+	require.Len(t, spans[0].Links(), 1)
+	assert.True(t, spans[0].Links()[0].SpanContext.Equal(spans[1].SpanContext()))
+}
@@ -0,0 +1,210 @@
+package gotell
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ErrorTypeOther is the error.type value for errors without a meaningful
+	// type, as per the semantic conventions.
+	ErrorTypeOther = "_OTHER"
+
+	errorTypeKey       = attribute.Key("error.type")
+	maxRecordedErrors  = 16
+	genericErrorFmt    = "*fmt.wrapError"
+	genericErrorsFmt   = "*fmt.wrapErrors"
+	genericErrorsJoin  = "*errors.joinError"
+	genericErrorString = "*errors.errorString"
+)
+
+// ErrorClassifier returns the error.type value for an error, and whether it
+// recognizes the error at all.
+type ErrorClassifier func(err error) (string, bool)
+
+//nolint:gochecknoglobals // process-wide error handling settings
+var (
+	errorRegistry    = &errorClassification{}
+	errorStackTraces atomic.Bool
+)
+
+// RegisterErrorClassifier adds a classifier that Span.Assert uses to set the
+// error.type attribute. Classifiers run in registration order, before any
+// other strategy.
+func RegisterErrorClassifier(classifier ErrorClassifier) {
+	errorRegistry.mutex.Lock()
+	defer errorRegistry.mutex.Unlock()
+
+	errorRegistry.classifiers = append(errorRegistry.classifiers, classifier)
+}
+
+// RegisterErrorType names a sentinel error. Span.Assert uses the name as the
+// error.type attribute of errors that match the sentinel through errors.Is.
+func RegisterErrorType(sentinel error, name string) {
+	RegisterErrorClassifier(func(err error) (string, bool) {
+		return name, errors.Is(err, sentinel)
+	})
+}
+
+// RegisterExpectedError marks errors that match the target through errors.Is
+// as expected. Span.Assert doesn't consider them failures, the same as
+// context.Canceled.
+func RegisterExpectedError(target error) {
+	errorRegistry.mutex.Lock()
+	defer errorRegistry.mutex.Unlock()
+
+	errorRegistry.expected = append(errorRegistry.expected, target)
+}
+
+// WithErrorStackTraces makes Span.Assert capture the stack trace on each
+// exception event it records.
+func WithErrorStackTraces() Option {
+	return OptionFn(func(opts *Options) error {
+		opts.ErrorStackTraces = true
+
+		return nil
+	})
+}
+
+// SetErrorStackTraces toggles the stack trace capture of Span.Assert at
+// runtime.
+//
+// See WithErrorStackTraces for details.
+func SetErrorStackTraces(enabled bool) {
+	errorStackTraces.Store(enabled)
+}
+
+// IsExpectedError reports whether the error isn't a failure, either because
+// it's a context.Canceled or it matches an error from RegisterExpectedError.
+//
+// Errors that wrap or join others are expected only if all of them are, so a
+// failure joined with a cancellation still counts as one.
+func IsExpectedError(err error) bool {
+	errorRegistry.mutex.RLock()
+	targets := slices.Concat([]error{context.Canceled}, errorRegistry.expected)
+	errorRegistry.mutex.RUnlock()
+
+	return isExpectedError(err, targets)
+}
+
+// isExpectedError walks the error tree, as errors.Is does, and requires every
+// branch to reach one of the targets.
+func isExpectedError(err error, targets []error) bool {
+	if err == nil {
+		return false
+	}
+
+	if slices.ContainsFunc(targets, func(target error) bool {
+		return matchesError(err, target)
+	}) {
+		return true
+	}
+
+	//nolint:errorlint // inspecting the tree structure itself
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		errs := wrapped.Unwrap()
+
+		return len(errs) > 0 && !slices.ContainsFunc(errs, func(err error) bool {
+			return !isExpectedError(err, targets)
+		})
+	case interface{ Unwrap() error }:
+		return isExpectedError(wrapped.Unwrap(), targets)
+	default:
+		return false
+	}
+}
+
+// matchesError reports whether the error itself, without unwrapping it, is
+// the target as per errors.Is.
+func matchesError(err, target error) bool {
+	//nolint:errorlint // compares a single node of the tree
+	if reflect.TypeOf(target).Comparable() && err == target {
+		return true
+	}
+
+	matcher, ok := err.(interface{ Is(target error) bool })
+
+	return ok && matcher.Is(target)
+}
+
+// ErrorType classifies an error for the error.type attribute. It returns the
+// first match of:
+//
+//   - the registered classifiers, including the RegisterErrorType sentinels
+//   - the concrete type of the first error in the chain that isn't a generic wrapper
+//   - ErrorTypeOther
+func ErrorType(err error) string {
+	errorRegistry.mutex.RLock()
+	classifiers := errorRegistry.classifiers
+	errorRegistry.mutex.RUnlock()
+
+	for _, classifier := range classifiers {
+		if name, ok := classifier(err); ok {
+			return name
+		}
+	}
+
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		if name := reflect.TypeOf(current).String(); !isGenericErrorType(name) {
+			return name
+		}
+	}
+
+	return ErrorTypeOther
+}
+
+type errorClassification struct {
+	mutex       sync.RWMutex
+	classifiers []ErrorClassifier
+	expected    []error
+}
+
+// recordErrors records each error of the tree as a separate exception event,
+// flattening errors.Join values. It stops after maxRecordedErrors events.
+func recordErrors(span trace.Span, err error) {
+	options := []trace.EventOption{}
+	if errorStackTraces.Load() {
+		options = append(options, trace.WithStackTrace(true))
+	}
+
+	pending := []error{err}
+
+	for recorded := 0; len(pending) > 0 && recorded < maxRecordedErrors; {
+		current := pending[0]
+		pending = pending[1:]
+
+		//nolint:errorlint // inspecting the tree structure itself
+		switch wrapped := current.(type) {
+		case interface{ Unwrap() []error }:
+			pending = append(pending, wrapped.Unwrap()...)
+
+			if reflect.TypeOf(current).String() == genericErrorsJoin {
+				continue
+			}
+		case interface{ Unwrap() error }:
+			if inner := wrapped.Unwrap(); inner != nil {
+				pending = append(pending, inner)
+			}
+		}
+
+		span.RecordError(current, options...)
+		recorded++
+	}
+}
+
+func isGenericErrorType(name string) bool {
+	switch name {
+	case genericErrorFmt, genericErrorsFmt, genericErrorsJoin, genericErrorString:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,44 @@
+package gotell
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RegisterProvider exposes registerProvider to the external tests.
+//
+//nolint:gochecknoglobals // test-only export
+var RegisterProvider = registerProvider
+
+// MaxTraceZRunningSpans exposes maxTraceZRunningSpans to the external tests.
+const MaxTraceZRunningSpans = maxTraceZRunningSpans
+
+// SplitFunctionName exposes splitFunctionName to the external tests.
+//
+//nolint:gochecknoglobals // test-only export
+var SplitFunctionName = splitFunctionName
+
+// NewHealthSpanPipeline wraps the processor and the exporter it sends to as
+// Initialize does, with the given queue capacity. It returns the health
+// report of the pipeline.
+func NewHealthSpanPipeline(
+	capacity int,
+	exporter sdktrace.SpanExporter,
+	processor func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor,
+) (sdktrace.SpanProcessor, func() SignalHealth) {
+	health := newExporterHealth(capacity)
+
+	return &healthSpanProcessor{
+		SpanProcessor: processor(&healthSpanExporter{SpanExporter: exporter, health: health}),
+		health:        health,
+	}, health.report
+}
+
+// NewRuntimeTraceSpan wraps the span as if the runtime/trace bridge opened a
+// task or region for it, which end closes.
+//
+//nolint:ireturn // mirrors the constructors
+func NewRuntimeTraceSpan(upstream trace.Span, end func()) Span {
+	//nolint:exhaustruct // not ended yet
+	return &span{Span: upstream, endRuntimeTrace: end}
+}
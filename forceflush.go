@@ -3,10 +3,7 @@ package gotell
 import (
 	"context"
 	"errors"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/log/global"
-	"golang.org/x/sync/errgroup"
+	"slices"
 )
 
 // ErrForceFlush happens when a provider fails to force flush
@@ -17,43 +14,24 @@ type ForceFlusher interface {
 	ForceFlush(ctx context.Context) error
 }
 
-// ForceFlush flushes the default logger, metric and tracer providers. Each will
-// run on a separate goroutine. It'll return the first error if any happens and
-// cancel the other routines.
+// ForceFlush flushes components added by RegisterFlusher in reverse
+// registration order, then the default tracer, logger and meter providers, in
+// this order, so telemetry derived from spans and logs ends up in the same
+// metrics export.
+//
+// Each target runs even if a previous one fails. The result joins all errors,
+// each tagged through a ProviderError or ComponentError. Use WithSignalTimeout to
+// bound individual providers.
 //
 // It works on any provider that implements the ForceFlusher interface. The OTEL
 // standard providers do, for instance. It'll ignore providers that don't.
-func ForceFlush(ctx context.Context) error {
-	group := errgroup.Group{}
-
-	group.Go(func() error {
-		err := tryForceFlush(ctx, global.GetLoggerProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	group.Go(func() error {
-		err := tryForceFlush(ctx, otel.GetMeterProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	group.Go(func() error {
-		err := tryForceFlush(ctx, otel.GetTracerProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	err := group.Wait()
+func ForceFlush(ctx context.Context, options ...LifecycleOption) error {
+	err := runLifecycle(
+		ctx,
+		newLifecycleOptions(options...),
+		withGlobalProviders(slices.Concat(flushRegistry.snapshot(false), providerEntries(false))),
+		tryForceFlush,
+	)
 	if err != nil {
 		return errors.Join(ErrForceFlush, err)
 	}
@@ -2,13 +2,25 @@ package gotell
 
 import (
 	"path"
+	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/attribute"
 )
 
+//nolint:gochecknoglobals // process-wide caches, keyed by program counters
+var (
+	callerInfoCache = &functionInfoCache{}
+	valueInfoCache  = &functionInfoCache{}
+)
+
 // FunctionInfo contains details about a function call.
+//
+// Values returned by this package are shared and must not be modified.
 type FunctionInfo struct {
 	Package      string
 	Filename     string
@@ -19,29 +31,33 @@ type FunctionInfo struct {
 }
 
 // GetFunctionInfo reports information about function invocations on the calling
-// goroutine's stack. It parses data from runtime.Caller and runtime.FuncForPC
-// to provide a structured result.
+// goroutine's stack. It parses data from the runtime call frames to provide a
+// structured result.
+//
+// Results are cached per call site, so repeated calls don't allocate.
 //
 // Skip equals zero means the caller of this function.
 func GetFunctionInfo(skip int) *FunctionInfo {
-	//nolint:exhaustruct // its filled down below
-	info := FunctionInfo{}
-
-	var pc uintptr
+	var pcs [1]uintptr
 
-	pc, info.Filepath, info.LineNumber, _ = runtime.Caller(skip + 1)
-	info.Directory, info.Filename = path.Split(info.Filepath)
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return &FunctionInfo{} //nolint:exhaustruct // unknown caller
+	}
 
-	parts := strings.Split(runtime.FuncForPC(pc).Name(), ".")
-	parts, info.FunctionName = parts[:len(parts)-1], parts[len(parts)-1]
+	return &callerInfoCache.load(pcs[0], resolveCallerInfo).info
+}
 
-	if parts[len(parts)-1][0] == '(' {
-		parts, info.FunctionName = parts[:len(parts)-1], parts[len(parts)-1]+"."+info.FunctionName
+// GetFunctionValueInfo reports information about a function value, such as a
+// callback, instead of a call site. The location is the function declaration.
+//
+// It returns nil if the value isn't a non-nil function.
+func GetFunctionValueInfo(fn any) *FunctionInfo {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil
 	}
 
-	info.Package = strings.Join(parts, ".")
-
-	return &info
+	return &valueInfoCache.load(value.Pointer(), resolveFunctionInfo).info
 }
 
 // FunctionAttributes is a convenience function that runs GetFunctionInfo then
@@ -53,12 +69,203 @@ func FunctionAttributes(skip int) []attribute.KeyValue {
 }
 
 // FunctionInfoAttributes converts the information from FunctionInfo into
-// OpenTelemetry semantic attributes.
+// OpenTelemetry semantic attributes. The code semantic conventions mode set by
+// WithCodeSemconv selects between the deprecated code.function, code.filepath,
+// code.lineno and code.namespace attributes; the current code.function.name,
+// code.file.path and code.line.number ones; or both. The Go runtime doesn't
+// report columns, so code.column.number is never set.
+//
+// Values from GetFunctionInfo and GetFunctionValueInfo have their attributes
+// precomputed. The result is shared in that case and must not be modified.
 func FunctionInfoAttributes(info *FunctionInfo) []attribute.KeyValue {
-	return []attribute.KeyValue{
-		attribute.String("code.function", info.FunctionName),
-		attribute.String("code.filepath", info.Filepath),
-		attribute.Int("code.lineno", info.LineNumber),
-		attribute.String("code.namespace", info.Package),
+	mode := codeSemconv.get()
+
+	if entry := callerInfoCache.lookup(info); entry != nil {
+		return entry.attributes[mode-1]
+	}
+
+	if entry := valueInfoCache.lookup(info); entry != nil {
+		return entry.attributes[mode-1]
+	}
+
+	return functionInfoAttributes(info, mode)
+}
+
+// QualifiedName returns the function name prefixed by its package path, as
+// expected by the code.function.name attribute.
+func (info *FunctionInfo) QualifiedName() string {
+	if info.Package == "" {
+		return info.FunctionName
+	}
+
+	return info.Package + "." + info.FunctionName
+}
+
+func functionInfoAttributes(info *FunctionInfo, mode SemconvStability) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 7) //nolint:mnd // 4 old plus 3 new attributes
+
+	if mode.emitsOld() {
+		attrs = append(attrs,
+			attribute.String("code.function", info.FunctionName),
+			attribute.String("code.filepath", info.Filepath),
+			attribute.Int("code.lineno", info.LineNumber),
+			attribute.String("code.namespace", info.Package),
+		)
+	}
+
+	if mode.emitsNew() {
+		attrs = append(attrs,
+			attribute.String("code.function.name", info.QualifiedName()),
+			attribute.String("code.file.path", info.Filepath),
+			attribute.Int("code.line.number", info.LineNumber),
+		)
+	}
+
+	return slices.Clip(attrs)
+}
+
+// functionInfoCache maps program counters to function information. Reads are
+// lock-free on an immutable snapshot; writes copy it. Call sites are finite, so
+// the cache stops growing once the program warms up.
+type functionInfoCache struct {
+	mutex    sync.Mutex
+	snapshot atomic.Pointer[functionInfoSnapshot]
+}
+
+type functionInfoSnapshot struct {
+	byPC   map[uintptr]*functionInfoEntry
+	byInfo map[*FunctionInfo]*functionInfoEntry
+}
+
+type functionInfoEntry struct {
+	info FunctionInfo
+	// attributes per resolved SemconvStability mode, offset by one
+	attributes [semconvModes][]attribute.KeyValue
+}
+
+func (cache *functionInfoCache) load(pc uintptr, resolve func(pc uintptr) FunctionInfo) *functionInfoEntry {
+	if snapshot := cache.snapshot.Load(); snapshot != nil {
+		if entry, ok := snapshot.byPC[pc]; ok {
+			return entry
+		}
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	current := cache.snapshot.Load()
+	if current == nil {
+		current = &functionInfoSnapshot{
+			byPC:   map[uintptr]*functionInfoEntry{},
+			byInfo: map[*FunctionInfo]*functionInfoEntry{},
+		}
+	}
+
+	if entry, ok := current.byPC[pc]; ok {
+		return entry
+	}
+
+	//nolint:exhaustruct // attributes are set below
+	entry := &functionInfoEntry{info: resolve(pc)}
+
+	for mode := SemconvOld; mode <= SemconvDuplicate; mode++ {
+		entry.attributes[mode-1] = functionInfoAttributes(&entry.info, mode)
+	}
+
+	next := &functionInfoSnapshot{
+		byPC:   make(map[uintptr]*functionInfoEntry, len(current.byPC)+1),
+		byInfo: make(map[*FunctionInfo]*functionInfoEntry, len(current.byInfo)+1),
+	}
+
+	for key, value := range current.byPC {
+		next.byPC[key] = value
+	}
+
+	for key, value := range current.byInfo {
+		next.byInfo[key] = value
+	}
+
+	next.byPC[pc] = entry
+	next.byInfo[&entry.info] = entry
+
+	cache.snapshot.Store(next)
+
+	return entry
+}
+
+func (cache *functionInfoCache) lookup(info *FunctionInfo) *functionInfoEntry {
+	snapshot := cache.snapshot.Load()
+	if snapshot == nil {
+		return nil
+	}
+
+	return snapshot.byInfo[info]
+}
+
+// resolveCallerInfo builds the function information of a return program
+// counter, as reported by runtime.Callers. It takes inlining into account.
+func resolveCallerInfo(pc uintptr) FunctionInfo {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	return newFunctionInfo(frame.Function, frame.File, frame.Line)
+}
+
+// resolveFunctionInfo builds the function information of a function entry
+// program counter, as reported by reflect.Value.Pointer.
+func resolveFunctionInfo(pc uintptr) FunctionInfo {
+	function := runtime.FuncForPC(pc)
+	if function == nil {
+		return newFunctionInfo("", "", 0)
+	}
+
+	filepath, lineNumber := function.FileLine(function.Entry())
+
+	return newFunctionInfo(function.Name(), filepath, lineNumber)
+}
+
+func newFunctionInfo(name, filepath string, lineNumber int) FunctionInfo {
+	info := FunctionInfo{
+		Package:      "",
+		Filename:     "",
+		Directory:    "",
+		Filepath:     filepath,
+		FunctionName: "",
+		LineNumber:   lineNumber,
+	}
+
+	info.Directory, info.Filename = path.Split(filepath)
+	info.Package, info.FunctionName = splitFunctionName(name)
+
+	return info
+}
+
+// splitFunctionName splits a runtime function name into its package path and
+// symbol. The package path ends at the first dot after the last slash, as the
+// runtime escapes dots in the last path element: gopkg.in/yaml%2ev3.Func
+// becomes gopkg.in/yaml.v3 and Func.
+//
+// The symbol drops generic instantiations, such as Map[...], and the method
+// value suffix -fm. Receivers and closure suffixes stay, as in (*T).Method or
+// Func.func1, to tell them apart.
+func splitFunctionName(name string) (string, string) {
+	slash := strings.LastIndexByte(name, '/')
+
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot < 0 {
+		return "", cleanFunctionName(name)
+	}
+
+	dot += slash + 1
+
+	return strings.ReplaceAll(name[:dot], "%2e", "."), cleanFunctionName(name[dot+1:])
+}
+
+func cleanFunctionName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+
+	if strings.Contains(name, "[...]") {
+		name = strings.ReplaceAll(name, "[...]", "")
 	}
+
+	return name
 }
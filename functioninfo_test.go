@@ -0,0 +1,174 @@
+package gotell_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wwmoraes/gotell"
+)
+
+const testPackage = "github.com/wwmoraes/gotell_test"
+
+type infoReceiver struct{}
+
+func (infoReceiver) value() *gotell.FunctionInfo {
+	return gotell.GetFunctionInfo(0)
+}
+
+func (*infoReceiver) pointer() *gotell.FunctionInfo {
+	return gotell.GetFunctionInfo(0)
+}
+
+//go:noinline
+func infoGeneric[T any]() *gotell.FunctionInfo {
+	return gotell.GetFunctionInfo(0)
+}
+
+//go:noinline
+func infoPlain() *gotell.FunctionInfo {
+	return gotell.GetFunctionInfo(0)
+}
+
+func TestGetFunctionInfo(t *testing.T) {
+	t.Parallel()
+
+	closure := func() *gotell.FunctionInfo {
+		return gotell.GetFunctionInfo(0)
+	}
+
+	methodValue := infoReceiver{}.value
+
+	tests := []struct {
+		name string
+		info *gotell.FunctionInfo
+		want string
+	}{
+		{name: "function", info: infoPlain(), want: "infoPlain"},
+		{name: "generic", info: infoGeneric[int](), want: "infoGeneric"},
+		{name: "value method", info: infoReceiver{}.value(), want: "infoReceiver.value"},
+		{name: "pointer method", info: (&infoReceiver{}).pointer(), want: "(*infoReceiver).pointer"},
+		{name: "method value", info: methodValue(), want: "infoReceiver.value"},
+		{name: "closure", info: closure(), want: "TestGetFunctionInfo.func1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testPackage, tt.info.Package)
+			assert.Equal(t, tt.want, tt.info.FunctionName)
+			assert.Equal(t, "functioninfo_test.go", tt.info.Filename)
+		})
+	}
+}
+
+func TestSplitFunctionName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		wantPackage string
+		wantSymbol  string
+	}{
+		{"main.main", "main", "main"},
+		{"github.com/wwmoraes/gotell.Trace[...]", "github.com/wwmoraes/gotell", "Trace"},
+		{"github.com/wwmoraes/gotell.(*span).End-fm", "github.com/wwmoraes/gotell", "(*span).End"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3", "Unmarshal"},
+		{"gopkg.in/yaml%2ev3.(*decoder).unmarshal.func1", "gopkg.in/yaml.v3", "(*decoder).unmarshal.func1"},
+		{"symbol", "", "symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkg, symbol := gotell.SplitFunctionName(tt.name)
+
+			assert.Equal(t, tt.wantPackage, pkg)
+			assert.Equal(t, tt.wantSymbol, symbol)
+		})
+	}
+}
+
+func TestGetFunctionInfoCache(t *testing.T) {
+	t.Parallel()
+
+	infos := make([]*gotell.FunctionInfo, 0, 2)
+	for range 2 {
+		infos = append(infos, gotell.GetFunctionInfo(0))
+	}
+
+	assert.Same(t, infos[0], infos[1])
+	assert.Equal(t, gotell.FunctionInfoAttributes(infos[0]), gotell.FunctionInfoAttributes(infos[1]))
+}
+
+func TestGetFunctionValueInfo(t *testing.T) {
+	t.Parallel()
+
+	info := gotell.GetFunctionValueInfo(infoGeneric[string])
+	assert.Equal(t, testPackage, info.Package)
+	assert.Equal(t, "infoGeneric", info.FunctionName)
+
+	assert.Nil(t, gotell.GetFunctionValueInfo(nil))
+	assert.Nil(t, gotell.GetFunctionValueInfo("not a function"))
+}
+
+func BenchmarkGetFunctionInfo(b *testing.B) {
+	b.ReportAllocs()
+
+	for range b.N {
+		_ = gotell.GetFunctionInfo(0)
+	}
+}
+
+func BenchmarkFunctionAttributes(b *testing.B) {
+	b.ReportAllocs()
+
+	for range b.N {
+		_ = gotell.FunctionAttributes(0)
+	}
+}
+
+//nolint:paralleltest // changes the process-wide code semantic conventions
+func TestFunctionInfoAttributesSemconv(t *testing.T) {
+	t.Cleanup(func() {
+		gotell.SetCodeSemconv(gotell.SemconvFromEnv)
+	})
+
+	info := &gotell.FunctionInfo{
+		Package:      testPackage,
+		Filename:     "functioninfo_test.go",
+		Directory:    "/src/",
+		Filepath:     "/src/functioninfo_test.go",
+		FunctionName: "infoPlain",
+		LineNumber:   42,
+	}
+
+	old := []attribute.KeyValue{
+		attribute.String("code.function", "infoPlain"),
+		attribute.String("code.filepath", "/src/functioninfo_test.go"),
+		attribute.Int("code.lineno", 42),
+		attribute.String("code.namespace", testPackage),
+	}
+
+	current := []attribute.KeyValue{
+		attribute.String("code.function.name", testPackage+".infoPlain"),
+		attribute.String("code.file.path", "/src/functioninfo_test.go"),
+		attribute.Int("code.line.number", 42),
+	}
+
+	gotell.SetCodeSemconv(gotell.SemconvOld)
+	assert.Equal(t, old, gotell.FunctionInfoAttributes(info))
+
+	gotell.SetCodeSemconv(gotell.SemconvNew)
+	assert.Equal(t, current, gotell.FunctionInfoAttributes(info))
+
+	gotell.SetCodeSemconv(gotell.SemconvDuplicate)
+	assert.Equal(t, append(old, current...), gotell.FunctionInfoAttributes(info))
+
+	// unknown modes fall back to the environment, which is unset in tests
+	gotell.SetCodeSemconv(gotell.SemconvDuplicate + 1)
+	assert.Equal(t, old, gotell.FunctionInfoAttributes(info))
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/XSAM/otelsql"
@@ -33,14 +34,25 @@ const (
 	// NAME contains the package name. It's used to separate all OpenTelemetry
 	// instances from the upstream.
 	NAME = "github.com/wwmoraes/gotell"
+
+	// DefaultShutdownTimeout is the deadline Run gives Shutdown unless set by
+	// WithShutdownTimeout.
+	DefaultShutdownTimeout = 5 * time.Second
 )
 
 // Options contains initialization properties that users can customize
 type Options struct {
-	LogsExporter    sdklog.Exporter
-	MetricsExporter sdkmetric.Exporter
-	Propagator      propagation.TextMapPropagator
-	TracesExporter  sdktrace.SpanExporter
+	LogsExporter     sdklog.Exporter
+	MetricsExporter  sdkmetric.Exporter
+	Propagator       propagation.TextMapPropagator
+	TracesExporter   sdktrace.SpanExporter
+	RuntimeTrace     bool
+	ErrorStackTraces bool
+	CodeSemconv      SemconvStability
+	HTTPSemconv      SemconvStability
+	ShutdownTimeout  time.Duration
+	SpanProcessors   []sdktrace.SpanProcessor
+	LogProcessors    []sdklog.Processor
 }
 
 // Option represents an object that can modify a set of initialization options
@@ -66,6 +78,7 @@ func NewOptions(ctx context.Context, options ...Option) (*Options, error) {
 		withDefaultLogsExporter(ctx),
 		withDefaultMetricsExporter(ctx),
 		withDefaultTracesExporter(ctx),
+		withDefaultShutdownTimeout(),
 	)
 
 	var err error
@@ -106,6 +119,38 @@ func WithTracesExporter(exporter sdktrace.SpanExporter) Option {
 	})
 }
 
+// WithSpanProcessor adds a span processor to the tracer provider, in addition
+// to the batch processor for the traces exporter. Processors run in the order
+// they're added, before the batch one.
+func WithSpanProcessor(processor sdktrace.SpanProcessor) Option {
+	return OptionFn(func(opts *Options) error {
+		opts.SpanProcessors = append(opts.SpanProcessors, processor)
+
+		return nil
+	})
+}
+
+// WithLogProcessor adds a log processor to the logger provider, in addition to
+// the batch processor for the logs exporter. Processors run in the order
+// they're added, before the batch one.
+func WithLogProcessor(processor sdklog.Processor) Option {
+	return OptionFn(func(opts *Options) error {
+		opts.LogProcessors = append(opts.LogProcessors, processor)
+
+		return nil
+	})
+}
+
+// WithShutdownTimeout sets the deadline Run gives Shutdown to flush telemetry
+// on exit. It defaults to DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return OptionFn(func(opts *Options) error {
+		opts.ShutdownTimeout = timeout
+
+		return nil
+	})
+}
+
 // WithPropagator sets a custom propagator
 func WithPropagator(propagator propagation.TextMapPropagator) Option {
 	return OptionFn(func(opts *Options) error {
@@ -130,6 +175,7 @@ func WithPropagator(propagator propagation.TextMapPropagator) Option {
 //   - uses OTLP exporters over gRPC (ignores OTEL_LOGS_EXPORTER, OTEL_METRICS_EXPORTER and OTEL_TRACES_EXPORTER)
 //   - uses batch processors for spans and logs
 //   - uses a periodic reader processor for metrics
+//   - wraps exporters to report their status through HealthHandler
 //
 // See https://opentelemetry.io/docs/specs/otel/configuration/sdk-environment-variables/
 func Initialize(ctx context.Context, res *resource.Resource, options ...Option) error {
@@ -138,32 +184,7 @@ func Initialize(ctx context.Context, res *resource.Resource, options ...Option)
 		return fmt.Errorf("failed to build options: %w", err)
 	}
 
-	otel.SetTextMapPropagator(opts.Propagator)
-
-	res, err = mergeResources(res)
-	if err != nil {
-		return fmt.Errorf("failed to merge resources: %w", err)
-	}
-
-	otel.SetTracerProvider(sdktrace.NewTracerProvider(
-		sdktrace.WithResource(res),
-		sdktrace.WithBatcher(opts.TracesExporter),
-	))
-
-	otel.SetMeterProvider(sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(opts.MetricsExporter)),
-	))
-
-	global.SetLoggerProvider(sdklog.NewLoggerProvider(
-		sdklog.WithResource(res),
-		sdklog.WithProcessor(sdklog.NewBatchProcessor(opts.LogsExporter)),
-	))
-
-	//nolint:wrapcheck // no need to bloat this one
-	return otelruntime.Start(
-		otelruntime.WithMinimumReadMemStatsInterval(time.Second),
-	)
+	return initialize(res, opts)
 }
 
 // Start creates a new span and a context containing its reference.
@@ -203,7 +224,8 @@ func StartNamed(ctx context.Context, spanName string, opts ...trace.SpanStartOpt
 //
 //nolint:ireturn // same practice as upstream to protect internal data
 func SpanFromContext(ctx context.Context) Span {
-	return &span{trace.SpanFromContext(ctx)}
+	//nolint:exhaustruct // runtime trace references belong to the span owner
+	return &span{Span: trace.SpanFromContext(ctx)}
 }
 
 // ContextLabeler is an idempotent way to retrieve a labeler from a context.
@@ -219,6 +241,9 @@ func ContextLabeler(ctx context.Context) (context.Context, *otelhttp.Labeler) {
 }
 
 // OpenSQL wraps database/sql.Open to add metadata and instrumentation.
+//
+// The caller owns the database handler. Use RegisterShutdowner with
+// ShutdownFn(db.Close) to close it on Shutdown.
 func OpenSQL(driverName, dataSourceName string) (*sql.DB, error) {
 	attributes := otelsql.WithAttributes(
 		attribute.String("db.system", driverName),
@@ -248,8 +273,11 @@ func Meter() metric.Meter {
 }
 
 // Tracer returns a new tracer configured by gotell.
+//
+// It uses the provider of the local span in ctx, if any. Remote span contexts,
+// such as extracted ones, carry a no-op provider, so it uses the global one.
 func Tracer(ctx context.Context) trace.Tracer {
-	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() && !span.SpanContext().IsRemote() {
 		return span.TracerProvider().Tracer(NAME)
 	}
 
@@ -262,13 +290,24 @@ func tracerStart(
 	spanName string,
 	opts ...trace.SpanStartOption,
 ) (context.Context, *span) {
-	info := GetFunctionInfo(2)
+	return tracerStartInfo(ctx, tracer, spanName, GetFunctionInfo(2), opts...)
+}
 
+// tracerStartInfo is the same as tracerStart but uses the provided function
+// information instead of the caller one.
+func tracerStartInfo(
+	ctx context.Context,
+	tracer trace.Tracer,
+	spanName string,
+	info *FunctionInfo,
+	opts ...trace.SpanStartOption,
+) (context.Context, *span) {
 	opts = append(opts, trace.WithAttributes(FunctionInfoAttributes(info)...))
 
-	if s := trace.SpanContextFromContext(ctx); s.IsValid() && s.IsRemote() {
+	parent := trace.SpanContextFromContext(ctx)
+	if parent.IsValid() && parent.IsRemote() {
 		opts = append(opts, trace.WithLinks(trace.Link{
-			SpanContext: s,
+			SpanContext: parent,
 			Attributes:  nil,
 		}))
 	}
@@ -277,9 +316,91 @@ func tracerStart(
 		spanName = info.FunctionName
 	}
 
+	ctx, endRuntimeTrace := startRuntimeTrace(ctx, parent, spanName)
+
 	ctx, upstreamSpan := tracer.Start(ctx, spanName, opts...)
 
-	return ctx, &span{upstreamSpan}
+	return ctx, &span{
+		Span:            upstreamSpan,
+		endRuntimeTrace: endRuntimeTrace,
+		ended:           atomic.Bool{},
+	}
+}
+
+func initialize(res *resource.Resource, opts *Options) error {
+	otel.SetTextMapPropagator(opts.Propagator)
+	SetRuntimeTrace(opts.RuntimeTrace)
+	SetErrorStackTraces(opts.ErrorStackTraces)
+	SetCodeSemconv(opts.CodeSemconv)
+	SetHTTPSemconv(opts.HTTPSemconv)
+
+	res, err := mergeResources(res)
+	if err != nil {
+		return fmt.Errorf("failed to merge resources: %w", err)
+	}
+
+	health := newTelemetryHealth()
+
+	tracerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+	}
+
+	for _, processor := range opts.SpanProcessors {
+		tracerOptions = append(tracerOptions, sdktrace.WithSpanProcessor(processor))
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(append(tracerOptions,
+		sdktrace.WithSpanProcessor(&healthSpanProcessor{
+			SpanProcessor: sdktrace.NewBatchSpanProcessor(&healthSpanExporter{
+				SpanExporter: opts.TracesExporter,
+				health:       health.traces,
+			}),
+			health: health.traces,
+		}),
+	)...)
+
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(&healthMetricExporter{
+			Exporter: opts.MetricsExporter,
+			health:   health.metrics,
+		})),
+	)
+
+	loggerOptions := []sdklog.LoggerProviderOption{
+		sdklog.WithResource(res),
+	}
+
+	for _, processor := range opts.LogProcessors {
+		loggerOptions = append(loggerOptions, sdklog.WithProcessor(processor))
+	}
+
+	loggerProvider := sdklog.NewLoggerProvider(append(loggerOptions,
+		sdklog.WithProcessor(&healthLogProcessor{
+			Processor: sdklog.NewBatchProcessor(&healthLogExporter{
+				Exporter: opts.LogsExporter,
+				health:   health.logs,
+			}),
+			health: health.logs,
+		}),
+	)...)
+
+	pipelineHealth.Store(health)
+
+	registerProvider(SignalTraces, tracerProvider)
+	registerProvider(SignalLogs, loggerProvider)
+	registerProvider(SignalMetrics, meterProvider)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	global.SetLoggerProvider(loggerProvider)
+
+	// bound to the meter provider, so it stops on Shutdown along with it
+	//nolint:wrapcheck // no need to bloat this one
+	return otelruntime.Start(
+		otelruntime.WithMeterProvider(meterProvider),
+		otelruntime.WithMinimumReadMemStatsInterval(time.Second),
+	)
 }
 
 func mergeResources(res *resource.Resource) (*resource.Resource, error) {
@@ -374,3 +495,15 @@ func withDefaultTracesExporter(ctx context.Context) Option {
 		return nil
 	})
 }
+
+func withDefaultShutdownTimeout() Option {
+	return OptionFn(func(opts *Options) error {
+		if opts.ShutdownTimeout > 0 {
+			return nil
+		}
+
+		opts.ShutdownTimeout = DefaultShutdownTimeout
+
+		return nil
+	})
+}
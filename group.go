@@ -0,0 +1,91 @@
+package gotell
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// Group is an errgroup.Group that traces its goroutines. It starts a fan-out
+// span on creation, a child span per task and ends the fan-out span once Wait
+// returns.
+//
+// The zero value isn't usable. Use NewGroup instead.
+type Group struct {
+	group *errgroup.Group
+	//nolint:containedctx // tasks derive their contexts from the group one
+	ctx  context.Context
+	span Span
+	once sync.Once
+}
+
+// NewGroup starts a fan-out span named after the caller and returns a Group
+// for it, along with a derived context. The same as errgroup.WithContext, the
+// context is canceled once a task returns an error or Wait returns.
+func NewGroup(ctx context.Context, opts ...trace.SpanStartOption) (*Group, context.Context) {
+	info := GetFunctionInfo(1)
+
+	ctx, span := tracerStartInfo(ctx, Tracer(ctx), info.FunctionName+" fan-out", info, opts...)
+
+	group, ctx := errgroup.WithContext(ctx)
+
+	return &Group{
+		group: group,
+		ctx:   ctx,
+		span:  span,
+		once:  sync.Once{},
+	}, ctx
+}
+
+// SetLimit limits the number of active tasks to at most n. A negative value
+// means no limit.
+//
+// See errgroup.Group.SetLimit for details.
+func (group *Group) SetLimit(n int) {
+	group.group.SetLimit(n)
+}
+
+// Go runs fn on a new goroutine within a child span of the fan-out one, named
+// after fn and with its code attributes. It asserts the child span with the
+// error fn returns.
+//
+// It blocks until the new goroutine can be added without exceeding the limit.
+func (group *Group) Go(fn func(ctx context.Context) error) {
+	info := GetFunctionValueInfo(fn)
+
+	group.group.Go(func() error {
+		return group.run(fn, info)
+	})
+}
+
+// TryGo is the same as Go, but it only starts the goroutine if it doesn't
+// exceed the limit. It reports whether it started the goroutine.
+func (group *Group) TryGo(fn func(ctx context.Context) error) bool {
+	info := GetFunctionValueInfo(fn)
+
+	return group.group.TryGo(func() error {
+		return group.run(fn, info)
+	})
+}
+
+// Wait blocks until all tasks return, then asserts and ends the fan-out span
+// with the first non-nil error, if any.
+func (group *Group) Wait() error {
+	err := group.group.Wait()
+
+	group.once.Do(func() {
+		group.span.Assert(err)
+		group.span.End()
+	})
+
+	return err
+}
+
+func (group *Group) run(fn func(ctx context.Context) error, info *FunctionInfo) error {
+	ctx, span := tracerStartInfo(group.ctx, Tracer(group.ctx), "", info)
+	defer endRecoveringSpan(span)
+
+	return span.Assert(fn(ctx))
+}
@@ -0,0 +1,43 @@
+package gotell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	group, _ := gotell.NewGroup(ctx)
+	group.SetLimit(1)
+
+	group.Go(func(context.Context) error {
+		return nil
+	})
+	group.Go(tracedFunction)
+
+	require.ErrorIs(t, group.Wait(), errTraced)
+	require.ErrorIs(t, group.Wait(), errTraced)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	fanOut := spans[2]
+	assert.Equal(t, "TestGroup fan-out", fanOut.Name())
+	assert.Equal(t, codes.Error, fanOut.Status().Code)
+
+	for _, span := range spans[:2] {
+		assert.Equal(t, fanOut.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+
+	assert.Equal(t, "tracedFunction", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,107 @@
+package gotell
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedactedHeaderValue replaces the values of sensitive captured headers.
+const RedactedHeaderValue = "REDACTED"
+
+// Environment variables that list, separated by commas, the headers to capture
+// as span attributes.
+const (
+	CaptureServerRequestHeadersEnv  = "OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_REQUEST"
+	CaptureServerResponseHeadersEnv = "OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_RESPONSE"
+	CaptureClientRequestHeadersEnv  = "OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_CLIENT_REQUEST"
+	CaptureClientResponseHeadersEnv = "OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_CLIENT_RESPONSE"
+)
+
+const (
+	requestHeaderPrefix  = "http.request.header."
+	responseHeaderPrefix = "http.response.header."
+)
+
+// DefaultRedactedHeaders returns the headers whose values are never recorded,
+// even if captured.
+func DefaultRedactedHeaders() []string {
+	return []string{
+		"Authorization",
+		"Cookie",
+		"Proxy-Authorization",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Auth-Token",
+		"X-Csrf-Token",
+	}
+}
+
+// RequestHeaderAttributes returns an http.request.header.<name> attribute for
+// each header in names present in headers. Values of DefaultRedactedHeaders are
+// redacted.
+func RequestHeaderAttributes(headers http.Header, names ...string) []attribute.KeyValue {
+	return headerAttributes(requestHeaderPrefix, headers, names, DefaultRedactedHeaders())
+}
+
+// ResponseHeaderAttributes returns an http.response.header.<name> attribute for
+// each header in names present in headers. Values of DefaultRedactedHeaders are
+// redacted.
+func ResponseHeaderAttributes(headers http.Header, names ...string) []attribute.KeyValue {
+	return headerAttributes(responseHeaderPrefix, headers, names, DefaultRedactedHeaders())
+}
+
+func headerAttributes(prefix string, headers http.Header, names, redacted []string) []attribute.KeyValue {
+	if len(names) == 0 || len(headers) == 0 {
+		return nil
+	}
+
+	filtered := FilterHeaders(headers, names...)
+	attrs := make([]attribute.KeyValue, 0, len(filtered))
+
+	for _, name := range names {
+		values, ok := filtered[name]
+		if !ok {
+			continue
+		}
+
+		if isRedactedHeader(name, redacted) {
+			values = slices.Repeat([]string{RedactedHeaderValue}, len(values))
+		}
+
+		attrs = append(attrs, attribute.StringSlice(prefix+strings.ToLower(name), values))
+	}
+
+	return attrs
+}
+
+func isRedactedHeader(name string, redacted []string) bool {
+	return slices.ContainsFunc(redacted, func(item string) bool {
+		return strings.EqualFold(name, item)
+	})
+}
+
+// capturedHeadersFromEnv returns the header names listed in the environment
+// variable.
+func capturedHeadersFromEnv(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	names := make([]string, 0, strings.Count(value, ",")+1)
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+
+	return names
+}
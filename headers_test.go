@@ -0,0 +1,67 @@
+package gotell_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestRequestHeaderAttributes(t *testing.T) {
+	t.Parallel()
+
+	headers := http.Header{
+		"Accept":        []string{"text/html", "application/json"},
+		"Authorization": []string{"Bearer secret"},
+		"X-Request-Id":  []string{"42"},
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []attribute.KeyValue
+	}{
+		{
+			name:  "none",
+			names: nil,
+			want:  nil,
+		},
+		{
+			name:  "multiple values",
+			names: []string{"Accept"},
+			want: []attribute.KeyValue{
+				attribute.StringSlice("http.request.header.accept", []string{"text/html", "application/json"}),
+			},
+		},
+		{
+			name:  "non-canonical name",
+			names: []string{"x-request-id"},
+			want: []attribute.KeyValue{
+				attribute.StringSlice("http.request.header.x-request-id", []string{"42"}),
+			},
+		},
+		{
+			name:  "redacted",
+			names: []string{"authorization"},
+			want: []attribute.KeyValue{
+				attribute.StringSlice("http.request.header.authorization", []string{gotell.RedactedHeaderValue}),
+			},
+		},
+		{
+			name:  "missing",
+			names: []string{"Cookie"},
+			want:  []attribute.KeyValue{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, gotell.RequestHeaderAttributes(headers, tt.names...))
+		})
+	}
+}
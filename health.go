@@ -0,0 +1,386 @@
+package gotell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultBatchQueueSize = 2048
+
+//nolint:gochecknoglobals // tracks the pipeline set up by Initialize
+var pipelineHealth atomic.Pointer[telemetryHealth]
+
+// SignalHealth reports the export status of a single signal pipeline.
+type SignalHealth struct {
+	// Connected reports whether the last export reached the collector, even if
+	// it then rejected the data.
+	Connected bool `json:"connected"`
+	// LastExportSucceeded is false until the first export succeeds.
+	LastExportSucceeded bool   `json:"lastExportSucceeded"`
+	LastError           string `json:"lastError,omitempty"`
+	// SecondsSinceLastSuccess is negative if no export succeeded yet.
+	SecondsSinceLastSuccess float64 `json:"secondsSinceLastSuccess"`
+	// QueueUtilization estimates the batch queue usage, from 0 to 1. It's
+	// always zero for metrics, which have no queue.
+	QueueUtilization float64 `json:"queueUtilization"`
+	// Dropped estimates the items the batch queue dropped while full.
+	Dropped             uint64 `json:"dropped"`
+	Exports             uint64 `json:"exports"`
+	Failures            uint64 `json:"failures"`
+	ConsecutiveFailures uint64 `json:"consecutiveFailures"`
+}
+
+// HealthReport is the telemetry pipeline status served by HealthHandler.
+type HealthReport struct {
+	Healthy bool                             `json:"healthy"`
+	Signals map[TelemetrySignal]SignalHealth `json:"signals"`
+}
+
+// HealthOptions contains properties that define when the pipeline is unhealthy.
+type HealthOptions struct {
+	// FailureThreshold is the amount of consecutive export failures of any
+	// signal that makes the pipeline unhealthy. Zero disables it.
+	FailureThreshold uint64
+	// MaxExportAge is the maximum time since the last successful export of any
+	// signal before the pipeline is unhealthy. Zero disables it.
+	MaxExportAge time.Duration
+}
+
+// HealthOption represents an object that can modify HealthOptions.
+type HealthOption interface {
+	Apply(opts *HealthOptions)
+}
+
+// HealthOptionFn is a function that implements HealthOption.
+type HealthOptionFn func(opts *HealthOptions)
+
+// Apply executes the function to apply its changes.
+func (fn HealthOptionFn) Apply(opts *HealthOptions) {
+	fn(opts)
+}
+
+// WithFailureThreshold sets the amount of consecutive export failures that
+// makes the pipeline unhealthy.
+func WithFailureThreshold(failures uint64) HealthOption {
+	return HealthOptionFn(func(opts *HealthOptions) {
+		opts.FailureThreshold = failures
+	})
+}
+
+// WithMaxExportAge sets the maximum time since the last successful export
+// before the pipeline is unhealthy.
+func WithMaxExportAge(age time.Duration) HealthOption {
+	return HealthOptionFn(func(opts *HealthOptions) {
+		opts.MaxExportAge = age
+	})
+}
+
+// Health reports the current status of the pipeline set up by Initialize. It
+// returns nil if Initialize wasn't called.
+func Health() *HealthReport {
+	health := pipelineHealth.Load()
+	if health == nil {
+		return nil
+	}
+
+	return health.report()
+}
+
+// HealthHandler serves the telemetry pipeline status as JSON, suited for
+// Kubernetes probes and dashboards.
+//
+// Data comes from wrappers around the exporters Initialize installs. It
+// responds with 503 Service Unavailable if Initialize wasn't called or any
+// signal exceeds the thresholds set through the options. Without options it
+// only reports the status.
+func HealthHandler(options ...HealthOption) http.Handler {
+	//nolint:exhaustruct // zero values disable the thresholds
+	opts := &HealthOptions{}
+
+	for _, option := range options {
+		option.Apply(opts)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := Health()
+		if report == nil {
+			report = &HealthReport{
+				Healthy: false,
+				Signals: map[TelemetrySignal]SignalHealth{},
+			}
+		} else {
+			report.Healthy = opts.healthy(report)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+func (opts *HealthOptions) healthy(report *HealthReport) bool {
+	for _, signal := range report.Signals {
+		if opts.FailureThreshold > 0 && signal.ConsecutiveFailures >= opts.FailureThreshold {
+			return false
+		}
+
+		if opts.MaxExportAge > 0 && signal.Exports > 0 &&
+			(signal.SecondsSinceLastSuccess < 0 || signal.SecondsSinceLastSuccess > opts.MaxExportAge.Seconds()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type telemetryHealth struct {
+	traces  *exporterHealth
+	logs    *exporterHealth
+	metrics *exporterHealth
+}
+
+func newTelemetryHealth() *telemetryHealth {
+	return &telemetryHealth{
+		traces:  newExporterHealth(envInt("OTEL_BSP_MAX_QUEUE_SIZE", defaultBatchQueueSize)),
+		logs:    newExporterHealth(envInt("OTEL_BLRP_MAX_QUEUE_SIZE", defaultBatchQueueSize)),
+		metrics: newExporterHealth(0),
+	}
+}
+
+func (health *telemetryHealth) report() *HealthReport {
+	return &HealthReport{
+		Healthy: true,
+		Signals: map[TelemetrySignal]SignalHealth{
+			SignalTraces:  health.traces.report(),
+			SignalLogs:    health.logs.report(),
+			SignalMetrics: health.metrics.report(),
+		},
+	}
+}
+
+// exporterHealth records the outcome of exports. The queue size is an
+// estimate: items count as queued from the time they end until their export
+// starts, and as dropped if they end while the estimate is at capacity, as the
+// batch processor drops them then.
+type exporterHealth struct {
+	mutex               sync.Mutex
+	lastSuccess         time.Time
+	lastErr             error
+	connected           bool
+	exports             uint64
+	failures            uint64
+	consecutiveFailures uint64
+
+	queued   atomic.Int64
+	dropped  atomic.Uint64
+	capacity int
+}
+
+func newExporterHealth(capacity int) *exporterHealth {
+	//nolint:exhaustruct // zero values mean no exports yet
+	return &exporterHealth{capacity: capacity}
+}
+
+// enqueue adds an item to the queue estimate, or counts it as dropped if the
+// estimate is full.
+func (health *exporterHealth) enqueue() {
+	for {
+		queued := health.queued.Load()
+		if queued >= int64(health.capacity) {
+			health.dropped.Add(1)
+
+			return
+		}
+
+		if health.queued.CompareAndSwap(queued, queued+1) {
+			return
+		}
+	}
+}
+
+// dequeue removes exported items from the queue estimate. Items that it counted
+// as dropped may still reach the exporter, so it stops at zero.
+func (health *exporterHealth) dequeue(items int) {
+	for {
+		queued := health.queued.Load()
+		if health.queued.CompareAndSwap(queued, max(0, queued-int64(items))) {
+			return
+		}
+	}
+}
+
+func (health *exporterHealth) record(err error) {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	health.exports++
+	health.lastErr = err
+	health.connected = !isConnectivityError(err)
+
+	if err != nil {
+		health.failures++
+		health.consecutiveFailures++
+
+		return
+	}
+
+	health.consecutiveFailures = 0
+	health.lastSuccess = time.Now()
+}
+
+func (health *exporterHealth) report() SignalHealth {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	report := SignalHealth{
+		Connected:               health.connected,
+		LastExportSucceeded:     health.exports > 0 && health.lastErr == nil,
+		LastError:               "",
+		SecondsSinceLastSuccess: -1,
+		QueueUtilization:        0,
+		Dropped:                 health.dropped.Load(),
+		Exports:                 health.exports,
+		Failures:                health.failures,
+		ConsecutiveFailures:     health.consecutiveFailures,
+	}
+
+	if health.lastErr != nil {
+		report.LastError = health.lastErr.Error()
+	}
+
+	if !health.lastSuccess.IsZero() {
+		report.SecondsSinceLastSuccess = time.Since(health.lastSuccess).Seconds()
+	}
+
+	if health.capacity > 0 {
+		report.QueueUtilization = min(1, float64(health.queued.Load())/float64(health.capacity))
+	}
+
+	return report
+}
+
+type healthSpanExporter struct {
+	sdktrace.SpanExporter
+
+	health *exporterHealth
+}
+
+func (exporter *healthSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	// the batch left the queue already
+	exporter.health.dequeue(len(spans))
+
+	err := exporter.SpanExporter.ExportSpans(ctx, spans)
+
+	exporter.health.record(err)
+
+	//nolint:wrapcheck // passthrough
+	return err
+}
+
+type healthSpanProcessor struct {
+	sdktrace.SpanProcessor
+
+	health *exporterHealth
+}
+
+func (processor *healthSpanProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	if span.SpanContext().IsSampled() {
+		processor.health.enqueue()
+	}
+
+	processor.SpanProcessor.OnEnd(span)
+}
+
+type healthLogExporter struct {
+	sdklog.Exporter
+
+	health *exporterHealth
+}
+
+func (exporter *healthLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	// the batch left the queue already
+	exporter.health.dequeue(len(records))
+
+	err := exporter.Exporter.Export(ctx, records)
+
+	exporter.health.record(err)
+
+	//nolint:wrapcheck // passthrough
+	return err
+}
+
+type healthLogProcessor struct {
+	sdklog.Processor
+
+	health *exporterHealth
+}
+
+func (processor *healthLogProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	processor.health.enqueue()
+
+	//nolint:wrapcheck // passthrough
+	return processor.Processor.OnEmit(ctx, record)
+}
+
+type healthMetricExporter struct {
+	sdkmetric.Exporter
+
+	health *exporterHealth
+}
+
+func (exporter *healthMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	err := exporter.Exporter.Export(ctx, metrics)
+
+	exporter.health.record(err)
+
+	//nolint:wrapcheck // passthrough
+	return err
+}
+
+// isConnectivityError reports whether the error means the exporter failed to
+// reach the collector at all.
+func isConnectivityError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	//nolint:exhaustive // other codes mean the collector replied
+	switch status.Code(err) {
+	case grpccodes.Unavailable, grpccodes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
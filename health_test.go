@@ -0,0 +1,212 @@
+package gotell_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var errRejected = errors.New("rejected")
+
+// fakeSpanExporter fails each export with the next error, if any.
+type fakeSpanExporter struct {
+	errs []error
+}
+
+func (exporter *fakeSpanExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	if len(exporter.errs) == 0 {
+		return nil
+	}
+
+	err := exporter.errs[0]
+	exporter.errs = exporter.errs[1:]
+
+	return err
+}
+
+func (*fakeSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// bufferSpanProcessor holds ended spans until flushed, like a batch processor
+// with an unbounded timeout.
+type bufferSpanProcessor struct {
+	exporter sdktrace.SpanExporter
+	spans    []sdktrace.ReadOnlySpan
+}
+
+func (*bufferSpanProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (processor *bufferSpanProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	processor.spans = append(processor.spans, span)
+}
+
+func (processor *bufferSpanProcessor) ForceFlush(ctx context.Context) error {
+	spans := processor.spans
+	processor.spans = nil
+
+	return processor.exporter.ExportSpans(ctx, spans)
+}
+
+func (*bufferSpanProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestHealthQueueUtilization(t *testing.T) {
+	t.Parallel()
+
+	processor, report := gotell.NewHealthSpanPipeline(
+		4,
+		&fakeSpanExporter{errs: nil},
+		func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+			return &bufferSpanProcessor{exporter: exporter, spans: nil}
+		},
+	)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	tracer := provider.Tracer("test")
+
+	end := func(spans int) {
+		for range spans {
+			_, span := tracer.Start(context.Background(), "test")
+			span.End()
+		}
+	}
+
+	end(2)
+	assert.InDelta(t, 0.5, report().QueueUtilization, 0)
+	assert.Zero(t, report().Dropped)
+
+	end(3)
+	assert.InDelta(t, 1, report().QueueUtilization, 0)
+	assert.Equal(t, uint64(1), report().Dropped)
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	assert.Zero(t, report().QueueUtilization)
+	assert.Equal(t, uint64(1), report().Exports)
+
+	// the estimate frees room after exports
+	end(1)
+	assert.InDelta(t, 0.25, report().QueueUtilization, 0)
+	assert.Equal(t, uint64(1), report().Dropped)
+}
+
+func TestHealthConnectivity(t *testing.T) {
+	t.Parallel()
+
+	unavailable := status.Error(grpccodes.Unavailable, "connection refused")
+	invalid := status.Error(grpccodes.InvalidArgument, "bad data")
+
+	tests := []struct {
+		name                 string
+		errs                 []error
+		wantConnected        bool
+		wantSucceeded        bool
+		wantError            string
+		wantFailures         uint64
+		wantConsecutive      uint64
+		wantSinceLastSuccess bool
+	}{
+		{
+			name:                 "success",
+			errs:                 nil,
+			wantConnected:        true,
+			wantSucceeded:        true,
+			wantError:            "",
+			wantFailures:         0,
+			wantConsecutive:      0,
+			wantSinceLastSuccess: true,
+		},
+		{
+			name:                 "unavailable",
+			errs:                 []error{unavailable},
+			wantConnected:        false,
+			wantSucceeded:        false,
+			wantError:            unavailable.Error(),
+			wantFailures:         1,
+			wantConsecutive:      1,
+			wantSinceLastSuccess: false,
+		},
+		{
+			name:                 "deadline",
+			errs:                 []error{context.DeadlineExceeded},
+			wantConnected:        false,
+			wantSucceeded:        false,
+			wantError:            context.DeadlineExceeded.Error(),
+			wantFailures:         1,
+			wantConsecutive:      1,
+			wantSinceLastSuccess: false,
+		},
+		{
+			name:                 "rejected",
+			errs:                 []error{invalid, errRejected},
+			wantConnected:        true,
+			wantSucceeded:        false,
+			wantError:            errRejected.Error(),
+			wantFailures:         2,
+			wantConsecutive:      2,
+			wantSinceLastSuccess: false,
+		},
+		{
+			name:                 "recovered",
+			errs:                 []error{unavailable, nil},
+			wantConnected:        true,
+			wantSucceeded:        true,
+			wantError:            "",
+			wantFailures:         1,
+			wantConsecutive:      0,
+			wantSinceLastSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			processor, report := gotell.NewHealthSpanPipeline(
+				4,
+				&fakeSpanExporter{errs: tt.errs},
+				func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+					return &bufferSpanProcessor{exporter: exporter, spans: nil}
+				},
+			)
+
+			exports := max(1, len(tt.errs))
+
+			for range exports {
+				_ = processor.ForceFlush(context.Background())
+			}
+
+			health := report()
+
+			assert.Equal(t, tt.wantConnected, health.Connected)
+			assert.Equal(t, tt.wantSucceeded, health.LastExportSucceeded)
+			assert.Equal(t, tt.wantError, health.LastError)
+			assert.Equal(t, uint64(exports), health.Exports)
+			assert.Equal(t, tt.wantFailures, health.Failures)
+			assert.Equal(t, tt.wantConsecutive, health.ConsecutiveFailures)
+			assert.Equal(t, tt.wantSinceLastSuccess, health.SecondsSinceLastSuccess >= 0)
+		})
+	}
+}
+
+func TestHealthHandlerUninitialized(t *testing.T) {
+	t.Parallel()
+
+	res := httptest.NewRecorder()
+
+	gotell.HealthHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.JSONEq(t, `{"healthy":false,"signals":{}}`, res.Body.String())
+}
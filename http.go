@@ -3,10 +3,13 @@ package gotell
 import (
 	"context"
 	"net/http"
+	"net/http/httptrace"
 	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
@@ -15,6 +18,11 @@ import (
 	"golang.org/x/exp/constraints"
 )
 
+const (
+	informationalStatusCodesKey = "gotell.http.response.informational_status_codes"
+	timeToFirstByteKey          = "gotell.http.server.time_to_first_byte"
+)
+
 // StatusText represents an HTTP status code text as per IANA definitions and
 // related RFCs.
 //
@@ -50,84 +58,252 @@ type MetricHandlerFn[T any] func(http.Handler, http.ResponseWriter, *http.Reques
 type MiddlewareWrapperFn func(ctx context.Context, w ResponseWriter, handle func())
 
 // SpanNameFormatter builds a HTTP span name using the method as prefix and
-// operation. It fallsback to the request line (as per RFC 7230 section 3.1.1.)
-// if operation is empty.
+// operation. It falls back to the route of the ServeMux pattern that matched the
+// request if operation is empty, or to the method alone if there's none.
+//
+// It never uses the request target, as that makes span names unbounded. For the
+// same reason, unknown methods become HTTP.
 func SpanNameFormatter(operation string, req *http.Request) string {
 	if req == nil {
 		return operation
 	}
 
-	if operation != "" {
-		return req.Method + " " + operation
+	method := parseMethod(req.Method)
+	if method == methodOther {
+		method = "HTTP"
 	}
 
-	// server-side requests
-	if req.RequestURI != "" {
-		return req.RequestURI
+	if operation == "" {
+		operation = PatternRoute(req)
 	}
 
-	// client-side requests
-	return req.Method + " " + req.URL.Path + " " + req.Proto
+	if operation == "" {
+		return method
+	}
+
+	return method + " " + operation
 }
 
 // WithInstrumentationMiddleware creates and enriches a span with HTTP request
 // and response attributes.
 //
+// It is a shorthand for NewInstrumentationMiddleware without options.
+func WithInstrumentationMiddleware(next http.Handler) http.Handler {
+	return NewInstrumentationMiddleware()(next)
+}
+
+// NewInstrumentationMiddleware creates a middleware that starts a server span
+// per request, enriches it with HTTP request and response attributes, and
+// records the HTTP server metrics.
+//
+// Attributes that inner handlers add to the context labeler, such as the ones
+// from WithBaggageLabeler, also apply to the request metrics.
+//
+// The span name and http.route use the http.ServeMux pattern that matched the
+// request, which is only known after the handler runs. Middlewares between this
+// one and the router that copy the request, such as with http.Request.WithContext,
+// hide the pattern; wrap the router with WithRouteCaptureMiddleware then.
+//
 // Some frameworks provide their own middleware implementation that does roughly
 // the same. You should use either the framework-specific or this one instead of
 // both to reduce the overhead per handler.
-func WithInstrumentationMiddleware(next http.Handler) http.Handler {
+func NewInstrumentationMiddleware(options ...InstrumentationOption) MiddlewareFn {
+	opts := NewInstrumentationOptions(options...)
+	instruments := opts.instruments()
+	metricFilter := metricAttributeFilter(opts.MetricAttributes)
+	activeFilter := metricAttributeFilter(activeRequestsAttributeKeys())
+	slowRequests := opts.slowRequestTracer()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !opts.accepts(r) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// resolve the tracer before the remote span context hides the local one
+			tracer := opts.tracer(r.Context())
+
+			if opts.BaggagePolicy != nil {
+				r = filterBaggage(r, opts.BaggagePolicy)
+			}
+
+			ctx := opts.propagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, labeler := ContextLabeler(ctx)
+
+			//nolint:exhaustruct // no route until the handler runs
+			routes := &routeHolder{extract: opts.RouteExtractor}
+			ctx = context.WithValue(ctx, routeHolderKey{}, routes)
+
+			reqAttributes := requestAttributes(r)
+
+			spanOptions := []trace.SpanStartOption{
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(labeler.Get()...),
+				trace.WithAttributes(reqAttributes...),
+				trace.WithAttributes(headerAttributes(requestHeaderPrefix, r.Header, opts.RequestHeaders, opts.RedactedHeaders)...),
+			}
+
+			for _, enricher := range opts.RequestEnrichers {
+				enrichedAttributes := enricher(r)
+				reqAttributes = append(reqAttributes, enrichedAttributes...)
+				spanOptions = append(spanOptions, trace.WithAttributes(enrichedAttributes...))
+			}
+
+			if rejection := traceContextRejection(ctx, r, opts); rejection != "" {
+				instruments.rejectedTraceContexts.Add(ctx, 1, metric.WithAttributes(
+					attribute.String(traceContextRejectionKey, rejection),
+				))
+
+				// the remote span context, if valid, becomes a link instead
+				if rejection == traceContextUntrusted {
+					spanOptions = append(spanOptions, trace.WithNewRoot())
+				}
+			}
+
+			ctx, span := tracerStartInfo(
+				ctx,
+				tracer,
+				opts.SpanNameFormatter("", r),
+				GetFunctionInfo(0),
+				spanOptions...,
+			)
+			defer span.End()
+
+			// the same set on both ends, or else the count never returns to zero
+			activeAttributeSet := metric.WithAttributeSet(attribute.NewSet(slices.Concat(
+				filterAttributes(reqAttributes, activeFilter),
+				labeler.Get(),
+			)...))
+
+			instruments.activeRequests.Add(ctx, 1, activeAttributeSet)
+			defer instruments.activeRequests.Add(ctx, -1, activeAttributeSet)
+
+			resWriter := NewResponseWriter(w)
+
+			start := time.Now()
+
+			// routers such as http.ServeMux set the pattern on this request, unless
+			// a middleware copies it before
+			req := r.WithContext(ctx)
+			body := recordRequestBody(req, opts.DecodeRequestBody)
+
+			next.ServeHTTP(resWriter, req)
+
+			end := time.Since(start)
+
+			slowRequests.observe(ctx, end)
+
+			resAttributes := responseWriterAttributes(resWriter)
+			resAttributes = append(resAttributes, body.attributes()...)
+
+			if route := routes.load(req); route != "" {
+				resAttributes = append(resAttributes, attribute.String("http.route", route))
+				span.SetName(opts.SpanNameFormatter(route, req))
+			}
+
+			statusCode, statusText := SpanStatusForKind(trace.SpanKindServer, resWriter.Status())
+			if statusCode == codes.Error {
+				resAttributes = append(resAttributes, errorTypeKey.String(strconv.Itoa(resWriter.Status())))
+			}
+
+			if statuses := resWriter.InformationalStatuses(); len(statuses) > 0 {
+				span.SetAttributes(attribute.IntSlice(informationalStatusCodesKey, statuses))
+			}
+
+			if ttfb := resWriter.TimeToFirstByte(); ttfb > 0 {
+				span.SetAttributes(attribute.Float64(timeToFirstByteKey, ttfb.Seconds()))
+			}
+
+			for _, enricher := range opts.ResponseEnrichers {
+				resAttributes = append(resAttributes, enricher(resWriter, req)...)
+			}
+
+			span.SetAttributes(resAttributes...)
+			span.SetAttributes(headerAttributes(responseHeaderPrefix, resWriter.Header(), opts.ResponseHeaders, opts.RedactedHeaders)...)
+			span.SetStatus(statusCode, statusText)
+
+			// only low-cardinality attributes, or else each request is a new series
+			instrumentAttributeSet := metric.WithAttributeSet(attribute.NewSet(slices.Concat(
+				filterAttributes(slices.Concat(reqAttributes, resAttributes), metricFilter),
+				labeler.Get(),
+			)...))
+
+			instruments.requestBodySize.Record(ctx, float64(body.size()), instrumentAttributeSet)
+			instruments.requestDuration.Record(ctx, end.Seconds(), instrumentAttributeSet)
+			instruments.responseBodySize.Record(ctx, float64(resWriter.ContentLength()), instrumentAttributeSet)
+		})
+	}
+}
+
+// WithRouteCaptureMiddleware passes the route of the requests the router
+// matched up to NewInstrumentationMiddleware, through any middlewares between
+// them that copy the request. Use it to wrap routers that set the pattern on
+// the request they receive, such as http.ServeMux:
+//
+//	NewInstrumentationMiddleware()(middleware(WithRouteCaptureMiddleware(mux)))
+func WithRouteCaptureMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r)
 
-		ctx, labeler := ContextLabeler(ctx)
+		if routes, ok := r.Context().Value(routeHolderKey{}).(*routeHolder); ok {
+			routes.capture(r)
+		}
+	})
+}
 
-		reqAttributes := RequestAttributes(r)
+type routeHolderKey struct{}
 
-		ctx, span := StartNamed(
-			ctx,
-			SpanNameFormatter("", r),
-			trace.WithSpanKind(trace.SpanKindServer),
-			trace.WithAttributes(labeler.Get()...),
-			trace.WithAttributes(reqAttributes...),
-		)
-		defer span.End()
+// routeHolder keeps the route captured by WithRouteCaptureMiddleware for the
+// instrumentation that created it.
+type routeHolder struct {
+	extract RouteExtractor
+	route   atomic.Pointer[string]
+}
+
+func (holder *routeHolder) capture(req *http.Request) {
+	if route := holder.extract(req); route != "" {
+		holder.route.Store(&route)
+	}
+}
 
-		httpServerActiveRequestsInstrument().Record(ctx, 1)
+// load returns the captured route, or else the one of the request.
+func (holder *routeHolder) load(req *http.Request) string {
+	if route := holder.route.Load(); route != nil {
+		return *route
+	}
 
-		resWriter := NewResponseWriter(w)
+	return holder.extract(req)
+}
 
-		start := time.Now()
+// traceContextRejection returns why the remote span context extracted into ctx
+// can't be the parent of the server span, or an empty string if it can.
+func traceContextRejection(ctx context.Context, req *http.Request, opts *InstrumentationOptions) string {
+	remote := trace.SpanContextFromContext(ctx)
 
-		next.ServeHTTP(resWriter, r.WithContext(ctx))
+	if !remote.IsValid() || !remote.IsRemote() {
+		// other propagators ignore the header, however valid it is
+		if req.Header.Get(traceparentHeader) != "" && handlesTraceparent(opts.propagator()) {
+			return traceContextMalformed
+		}
 
-		end := time.Since(start)
+		return ""
+	}
+
+	if !opts.trusts(req) {
+		return traceContextUntrusted
+	}
 
-		resAttributes := ResponseWriterAttributes(resWriter)
-		span.SetAttributes(resAttributes...)
-		span.SetStatus(SpanStatusForStatusCode(resWriter.Status()))
-
-		instrumentAttributeSet := attribute.NewSet(slices.Concat(
-			reqAttributes,
-			resAttributes,
-		)...)
-
-		httpServerRequestBodySizeInstrument().Record(
-			ctx,
-			float64(r.ContentLength),
-			metric.WithAttributeSet(instrumentAttributeSet),
-		)
-		httpServerRequestDurationInstrument().Record(
-			ctx,
-			end.Seconds(),
-			metric.WithAttributeSet(instrumentAttributeSet),
-		)
-		httpServerResponseBodySizeInstrument().Record(
-			ctx,
-			float64(resWriter.ContentLength()),
-			metric.WithAttributeSet(instrumentAttributeSet),
-		)
-		httpServerActiveRequestsInstrument().Record(ctx, -1, metric.WithAttributeSet(instrumentAttributeSet))
+	return ""
+}
+
+// handlesTraceparent reports whether the propagator extracts the W3C Trace
+// Context traceparent header.
+func handlesTraceparent(propagator propagation.TextMapPropagator) bool {
+	return slices.ContainsFunc(propagator.Fields(), func(field string) bool {
+		return strings.EqualFold(field, traceparentHeader)
 	})
 }
 
@@ -160,32 +336,75 @@ func WithHistogramMiddleware[T Number](
 
 // WithMetrics instruments another HTTP round tripper with request and response
 // metrics.
+//
+// Requests count as active until their response body is done. Use
+// TrackConnections on the underlying transport to record connection metrics.
 func WithMetrics(next http.RoundTripper) http.RoundTripper {
-	return RoundTripperFn(func(req *http.Request) (*http.Response, error) {
+	return wrapRoundTripper(next, func(req *http.Request) (*http.Response, error) {
 		// we just pretend we didn't see anything...
 		if req == nil {
+			//nolint:wrapcheck // passthrough
 			return next.RoundTrip(req)
 		}
 
-		start := time.Now()
-		res, err := next.RoundTrip(req)
-		end := time.Since(start)
-
 		ctx, labeler := ContextLabeler(req.Context())
 
-		attrs := metric.WithAttributes(labeler.Get()...)
-		httpClientRequestDuration().Record(ctx, end.Seconds(), attrs)
-		httpClientRequestBodySize().Record(ctx, float64(req.ContentLength), attrs)
+		requestAttributes := ClientRequestAttributes(req)
+
+		// the same set on both ends, or else the count never returns to zero
+		activeAttributes := metric.WithAttributes(requestAttributes...)
+		httpClientActiveRequests().Add(ctx, 1, activeAttributes)
+
+		var conn atomic.Pointer[trackedConn]
+
+		//nolint:exhaustruct // only the connection acquisition matters
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				if tracked, ok := connectionOf(info.Conn); ok {
+					tracked.acquire()
+					conn.Store(tracked)
+				}
+			},
+		})
+
+		done := func(error) {
+			if tracked := conn.Load(); tracked != nil {
+				tracked.release()
+			}
+
+			httpClientActiveRequests().Add(ctx, -1, activeAttributes)
+		}
+
+		start := time.Now()
+		res, err := next.RoundTrip(req.WithContext(ctx))
+		end := time.Since(start)
 
 		var contentLength int64
 
+		instrumentAttributes := requestAttributes
+
 		// response may be nil, such as on context cancellation
 		if res != nil {
 			contentLength = res.ContentLength
+			instrumentAttributes = append(instrumentAttributes, ResponseAttributes(res)...)
+		}
+
+		if err != nil {
+			instrumentAttributes = append(instrumentAttributes, errorTypeKey.String(ErrorType(err)))
 		}
 
+		attrs := metric.WithAttributes(slices.Concat(instrumentAttributes, labeler.Get())...)
+		httpClientRequestDuration().Record(ctx, end.Seconds(), attrs)
+		httpClientRequestBodySize().Record(ctx, float64(req.ContentLength), attrs)
+
 		httpClientResponseBodySize().Record(ctx, float64(contentLength), attrs)
 
+		if err != nil || res == nil {
+			done(err)
+		} else {
+			onBodyDone(res, done)
+		}
+
 		//nolint:wrapcheck // passthrough
 		return res, err
 	})
@@ -210,13 +429,35 @@ func FilterHeaders(headers http.Header, includedKeys ...string) http.Header {
 }
 
 // SpanStatusForStatusCode assigns a span code and message for a HTTP status
-// code.
+// code. It uses the client semantics, which mark any 4xx as an error.
+//
+// Deprecated: use SpanStatusForKind, as server spans must not treat 4xx
+// responses as errors.
 func SpanStatusForStatusCode(statusCode int) (codes.Code, StatusText) {
+	return SpanStatusForKind(trace.SpanKindClient, statusCode)
+}
+
+// SpanStatusForKind assigns a span code and message for a HTTP status code as
+// per the semantic conventions of the span kind. Server spans consider 4xx
+// responses a client fault and leave the status unset, while other kinds mark
+// them as errors. Status codes outside the valid range are always errors,
+// except for zero, which means no status was written and leaves it unset.
+//
+// See https://opentelemetry.io/docs/specs/semconv/http/http-spans/#status
+func SpanStatusForKind(kind trace.SpanKind, statusCode int) (codes.Code, StatusText) {
 	code := codes.Unset
 
-	if statusCode >= http.StatusBadRequest {
+	switch {
+	case statusCode == 0:
+	case statusCode < 100 || statusCode > 599:
 		code = codes.Error
-	} else if statusCode >= http.StatusOK {
+	case statusCode >= http.StatusInternalServerError:
+		code = codes.Error
+	case statusCode >= http.StatusBadRequest:
+		if kind != trace.SpanKindServer {
+			code = codes.Error
+		}
+	case statusCode >= http.StatusOK:
 		code = codes.Ok
 	}
 
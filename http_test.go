@@ -0,0 +1,672 @@
+package gotell_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wwmoraes/gotell"
+)
+
+// globalMetricReader sets up the global meter provider once per test binary, as
+// the global instruments delegate to the first provider set only. The provider
+// hides its Shutdown method so tests that call gotell.Shutdown don't stop it.
+//
+//nolint:gochecknoglobals // shared by tests that check global instruments
+var globalMetricReader = sync.OnceValue(func() *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+
+	otel.SetMeterProvider(unmanagedMeterProvider{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+
+	return reader
+})
+
+type unmanagedMeterProvider struct {
+	metric.MeterProvider
+}
+
+// collectMetric returns the data of the named global metric, or nil if it has
+// no data yet.
+func collectMetric(t *testing.T, name string) metricdata.Aggregation {
+	t.Helper()
+
+	return collectReaderMetric(t, globalMetricReader(), name)
+}
+
+// collectReaderMetric returns the data of the named metric of the reader, or nil
+// if it has no data yet.
+func collectReaderMetric(t *testing.T, reader sdkmetric.Reader, name string) metricdata.Aggregation {
+	t.Helper()
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+
+	for _, scope := range data.ScopeMetrics {
+		for _, instrument := range scope.Metrics {
+			if instrument.Name == name {
+				return instrument.Data
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasAttributes reports whether the set contains all attributes.
+func hasAttributes(set attribute.Set, attrs ...attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if value, found := set.Value(attr.Key); !found || value != attr.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// int64SumValue collects the sum of the data points of an int64 sum metric
+// whose attributes contain all attrs.
+func int64SumValue(t *testing.T, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	return int64ReaderSumValue(t, globalMetricReader(), name, attrs...)
+}
+
+// int64ReaderSumValue is int64SumValue for the metrics of the reader.
+func int64ReaderSumValue(t *testing.T, reader sdkmetric.Reader, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	data := collectReaderMetric(t, reader, name)
+	if data == nil {
+		return 0
+	}
+
+	sum, ok := data.(metricdata.Sum[int64])
+	require.True(t, ok, "unexpected %s data type %T", name, data)
+
+	var total int64
+
+	for _, point := range sum.DataPoints {
+		if hasAttributes(point.Attributes, attrs...) {
+			total += point.Value
+		}
+	}
+
+	return total
+}
+
+// float64HistogramCount collects the count of the data points of a float64
+// histogram metric whose attributes contain all attrs.
+func float64HistogramCount(t *testing.T, name string, attrs ...attribute.KeyValue) uint64 {
+	t.Helper()
+
+	data := collectMetric(t, name)
+	if data == nil {
+		return 0
+	}
+
+	histogram, ok := data.(metricdata.Histogram[float64])
+	require.True(t, ok, "unexpected %s data type %T", name, data)
+
+	var total uint64
+
+	for _, point := range histogram.DataPoints {
+		if hasAttributes(point.Attributes, attrs...) {
+			total += point.Count
+		}
+	}
+
+	return total
+}
+
+func TestInstrumentationMiddlewareActiveRequests(t *testing.T) {
+	t.Parallel()
+
+	const (
+		requests = 32
+		host     = "active.test"
+	)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var started sync.WaitGroup
+
+	started.Add(requests)
+
+	release := make(chan struct{})
+
+	middleware := gotell.NewInstrumentationMiddleware(gotell.WithMeterProvider(provider))
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+
+		if r.URL.Path == "/panic" {
+			panic(http.ErrAbortHandler)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var done sync.WaitGroup
+
+	for index := range requests {
+		target := "http://" + host + "/"
+		if index%4 == 0 {
+			target += "panic"
+		}
+
+		done.Add(1)
+
+		go func() {
+			defer done.Done()
+			defer func() { _ = recover() }()
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		}()
+	}
+
+	started.Wait()
+	assert.Equal(t, int64(requests), int64ReaderSumValue(t, reader, "http.server.active_requests"))
+
+	close(release)
+	done.Wait()
+	assert.Equal(t, int64(0), int64ReaderSumValue(t, reader, "http.server.active_requests"))
+}
+
+func TestNewInstrumentationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	const (
+		traceparent   = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		remoteTraceID = "0af7651916cd43dd8448eb211c80319c"
+	)
+
+	tests := []struct {
+		name        string
+		target      string
+		options     []gotell.InstrumentationOption
+		wantSpans   int
+		wantAttr    attribute.KeyValue
+		wantTraceID bool
+	}{
+		{
+			name:        "defaults",
+			target:      "/",
+			options:     nil,
+			wantSpans:   1,
+			wantAttr:    attribute.String("http.request.method", http.MethodGet),
+			wantTraceID: true,
+		},
+		{
+			name:   "filtered",
+			target: "/healthz",
+			options: []gotell.InstrumentationOption{
+				gotell.WithRequestFilter(func(req *http.Request) bool {
+					return req.URL.Path != "/healthz"
+				}),
+			},
+			wantSpans:   0,
+			wantAttr:    attribute.KeyValue{},
+			wantTraceID: false,
+		},
+		{
+			name:   "enriched",
+			target: "/",
+			options: []gotell.InstrumentationOption{
+				gotell.WithRequestEnricher(func(*http.Request) []attribute.KeyValue {
+					return []attribute.KeyValue{attribute.String("tenant", "acme")}
+				}),
+			},
+			wantSpans:   1,
+			wantAttr:    attribute.String("tenant", "acme"),
+			wantTraceID: true,
+		},
+		{
+			name:        "public endpoint",
+			target:      "/",
+			options:     []gotell.InstrumentationOption{gotell.WithPublicEndpoint()},
+			wantSpans:   1,
+			wantAttr:    attribute.String("http.request.method", http.MethodGet),
+			wantTraceID: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			options := append([]gotell.InstrumentationOption{
+				gotell.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+				gotell.WithTextMapPropagator(propagation.TraceContext{}),
+			}, tt.options...)
+
+			handler := gotell.NewInstrumentationMiddleware(options...)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Traceparent", traceparent)
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, tt.wantSpans)
+
+			if tt.wantSpans == 0 {
+				return
+			}
+
+			assert.Contains(t, spans[0].Attributes(), tt.wantAttr)
+			assert.Equal(t, tt.wantTraceID, spans[0].SpanContext().TraceID().String() == remoteTraceID)
+			require.Len(t, spans[0].Links(), 1)
+			assert.Equal(t, remoteTraceID, spans[0].Links()[0].SpanContext.TraceID().String())
+		})
+	}
+}
+
+func TestInstrumentationMiddlewareRoute(t *testing.T) {
+	t.Parallel()
+
+	type copiedKey struct{}
+
+	// copies the request, as middlewares that change the context do
+	copying := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), copiedKey{}, true)))
+		})
+	}
+
+	tests := []struct {
+		name   string
+		router func(mux *http.ServeMux) http.Handler
+	}{
+		{
+			name: "router",
+			router: func(mux *http.ServeMux) http.Handler {
+				return mux
+			},
+		},
+		{
+			name: "captured router",
+			router: func(mux *http.ServeMux) http.Handler {
+				return gotell.WithRouteCaptureMiddleware(mux)
+			},
+		},
+		{
+			name: "captured router behind a copying middleware",
+			router: func(mux *http.ServeMux) http.Handler {
+				return copying(gotell.WithRouteCaptureMiddleware(mux))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			recorder := tracetest.NewSpanRecorder()
+
+			handler := gotell.NewInstrumentationMiddleware(
+				gotell.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+			)(tt.router(mux))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "GET /users/{id}", spans[0].Name())
+			assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/users/{id}"))
+		})
+	}
+}
+
+func TestNewInstrumentationMiddlewareRequestBody(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("gotell", 100)
+
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(payload))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	tests := []struct {
+		name      string
+		body      []byte
+		encoding  string
+		options   []gotell.InstrumentationOption
+		readLimit int64
+		want      []attribute.KeyValue
+	}{
+		{
+			name:      "chunked",
+			body:      []byte(payload),
+			encoding:  "",
+			options:   nil,
+			readLimit: -1,
+			want: []attribute.KeyValue{
+				attribute.Int64("http.request.body.size", int64(len(payload))),
+				attribute.Bool("gotell.http.request.body.early_close", false),
+			},
+		},
+		{
+			name:      "partial read",
+			body:      []byte(payload),
+			encoding:  "",
+			options:   nil,
+			readLimit: 6,
+			want: []attribute.KeyValue{
+				attribute.Int64("http.request.body.size", 6),
+				attribute.Bool("gotell.http.request.body.early_close", true),
+			},
+		},
+		{
+			name:      "compressed",
+			body:      compressed.Bytes(),
+			encoding:  "gzip",
+			options:   nil,
+			readLimit: -1,
+			want: []attribute.KeyValue{
+				attribute.Int64("http.request.body.size", int64(compressed.Len())),
+			},
+		},
+		{
+			name:      "decoded",
+			body:      compressed.Bytes(),
+			encoding:  "gzip",
+			options:   []gotell.InstrumentationOption{gotell.WithRequestBodyDecoding()},
+			readLimit: -1,
+			want: []attribute.KeyValue{
+				attribute.Int64("http.request.body.size", int64(compressed.Len())),
+				attribute.Int64("gotell.http.request.body.decoded_size", int64(len(payload))),
+				attribute.Bool("gotell.http.request.body.early_close", false),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			options := append([]gotell.InstrumentationOption{
+				gotell.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+			}, tt.options...)
+
+			handler := gotell.NewInstrumentationMiddleware(options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reader io.Reader = r.Body
+				if tt.readLimit >= 0 {
+					reader = io.LimitReader(r.Body, tt.readLimit)
+				}
+
+				_, err := io.Copy(io.Discard, reader)
+				assert.NoError(t, err)
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			// io.MultiReader hides the length, as in chunked uploads
+			req := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(bytes.NewReader(tt.body)))
+			req.ContentLength = -1
+
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+
+			for _, attr := range tt.want {
+				assert.Contains(t, spans[0].Attributes(), attr)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // sets the global tracer provider and propagator
+func TestInstrumentationMiddlewareGlobalTracer(t *testing.T) {
+	const (
+		remoteTraceID = "0af7651916cd43dd8448eb211c80319c"
+		traceparent   = "00-" + remoteTraceID + "-b7ad6b7169203331-01"
+		rejection     = "gotell.trace_context.rejection"
+	)
+
+	globalMetricReader()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(middleware http.Handler, traceparent string) sdktrace.ReadOnlySpan {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Traceparent", traceparent)
+
+		before := len(recorder.Ended())
+
+		middleware.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, before+1)
+
+		return spans[before]
+	}
+
+	t.Run("trusted", func(t *testing.T) {
+		span := serve(gotell.WithInstrumentationMiddleware(handler), traceparent)
+
+		assert.Equal(t, remoteTraceID, span.SpanContext().TraceID().String())
+		assert.Equal(t, remoteTraceID, span.Parent().TraceID().String())
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		before := int64SumValue(t, "gotell.http.server.trace_context.rejected", attribute.String(rejection, "untrusted"))
+
+		span := serve(gotell.NewInstrumentationMiddleware(gotell.WithPublicEndpoint())(handler), traceparent)
+
+		assert.NotEqual(t, remoteTraceID, span.SpanContext().TraceID().String())
+		assert.False(t, span.Parent().IsValid(), "untrusted span has a parent")
+		require.Len(t, span.Links(), 1)
+		assert.Equal(t, remoteTraceID, span.Links()[0].SpanContext.TraceID().String())
+		assert.Equal(t, before+1, int64SumValue(t, "gotell.http.server.trace_context.rejected",
+			attribute.String(rejection, "untrusted")))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		before := int64SumValue(t, "gotell.http.server.trace_context.rejected", attribute.String(rejection, "malformed"))
+
+		span := serve(gotell.NewServeMux(), "00-not-a-trace-context")
+
+		assert.False(t, span.Parent().IsValid(), "malformed span has a parent")
+		assert.Empty(t, span.Links())
+		assert.Equal(t, before+1, int64SumValue(t, "gotell.http.server.trace_context.rejected",
+			attribute.String(rejection, "malformed")))
+	})
+}
+
+func TestInstrumentationMiddlewareMalformedTraceContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		propagator  propagation.TextMapPropagator
+		traceparent string
+		want        int64
+	}{
+		{
+			name:        "malformed",
+			propagator:  propagation.TraceContext{},
+			traceparent: "00-not-a-trace-context",
+			want:        1,
+		},
+		{
+			name:        "composite",
+			propagator:  propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{}),
+			traceparent: "00-not-a-trace-context",
+			want:        1,
+		},
+		{
+			name:        "unhandled",
+			propagator:  propagation.Baggage{},
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			want:        0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := sdkmetric.NewManualReader()
+
+			handler := gotell.NewInstrumentationMiddleware(
+				gotell.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+				gotell.WithTracerProvider(sdktrace.NewTracerProvider()),
+				gotell.WithTextMapPropagator(tt.propagator),
+			)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Traceparent", tt.traceparent)
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, int64ReaderSumValue(t, reader, "gotell.http.server.trace_context.rejected",
+				attribute.String("gotell.trace_context.rejection", "malformed")))
+		})
+	}
+}
+
+func TestInstrumentationMiddlewareMetricAttributes(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	handler := gotell.NewInstrumentationMiddleware(
+		gotell.WithMeterProvider(provider),
+		gotell.WithTracerProvider(tracerProvider),
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	for _, method := range []string{"PURGE", "SPAM", http.MethodGet} {
+		req := httptest.NewRequest(method, "http://any.host.example:8080/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	data := collectReaderMetric(t, reader, "http.server.request.duration")
+	require.NotNil(t, data)
+
+	histogram, ok := data.(metricdata.Histogram[float64])
+	require.True(t, ok, "unexpected data type %T", data)
+
+	sets := make([]attribute.Set, 0, len(histogram.DataPoints))
+	for _, point := range histogram.DataPoints {
+		sets = append(sets, point.Attributes)
+	}
+
+	assert.ElementsMatch(t, []attribute.Set{
+		attribute.NewSet(
+			attribute.String("http.request.method", "_OTHER"),
+			attribute.Int("http.response.status_code", http.StatusAccepted),
+			attribute.String("network.protocol.name", "http"),
+			attribute.String("network.protocol.version", "1.1"),
+			attribute.String("url.scheme", "http"),
+		),
+		attribute.NewSet(
+			attribute.String("http.request.method", http.MethodGet),
+			attribute.Int("http.response.status_code", http.StatusAccepted),
+			attribute.String("network.protocol.name", "http"),
+			attribute.String("network.protocol.version", "1.1"),
+			attribute.String("url.scheme", "http"),
+		),
+	}, sets)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "HTTP", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.request.method_original", "PURGE"))
+}
+
+func TestSpanStatusForKind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		kind       trace.SpanKind
+		statusCode int
+		want       codes.Code
+	}{
+		{"server unwritten", trace.SpanKindServer, 0, codes.Unset},
+		{"server informational", trace.SpanKindServer, http.StatusContinue, codes.Unset},
+		{"server ok", trace.SpanKindServer, http.StatusOK, codes.Ok},
+		{"server redirect", trace.SpanKindServer, http.StatusFound, codes.Ok},
+		{"server not found", trace.SpanKindServer, http.StatusNotFound, codes.Unset},
+		{"server error", trace.SpanKindServer, http.StatusBadGateway, codes.Error},
+		{"server invalid", trace.SpanKindServer, 600, codes.Error},
+		{"client ok", trace.SpanKindClient, http.StatusOK, codes.Ok},
+		{"client not found", trace.SpanKindClient, http.StatusNotFound, codes.Error},
+		{"client error", trace.SpanKindClient, http.StatusServiceUnavailable, codes.Error},
+		{"internal not found", trace.SpanKindInternal, http.StatusNotFound, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, text := gotell.SpanStatusForKind(tt.kind, tt.statusCode)
+
+			assert.Equal(t, tt.want, code)
+			assert.Equal(t, http.StatusText(tt.statusCode), text)
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package gotell
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestFilter reports whether to instrument a request.
+type RequestFilter func(req *http.Request) bool
+
+// SpanNameFormatterFn builds a span name out of an operation, such as a route,
+// and the request.
+type SpanNameFormatterFn func(operation string, req *http.Request) string
+
+// RequestEnricher returns extra attributes for the server span of a request.
+type RequestEnricher func(req *http.Request) []attribute.KeyValue
+
+// ResponseEnricher returns extra attributes for the server span once the
+// handler returns.
+type ResponseEnricher func(w ResponseWriter, req *http.Request) []attribute.KeyValue
+
+// RouteExtractor returns the low-cardinality route template that matched a
+// request, such as /users/{id}, or an empty string if there's none.
+type RouteExtractor func(req *http.Request) string
+
+// InstrumentationOptions contains properties that customize the HTTP server
+// instrumentation.
+type InstrumentationOptions struct {
+	// Filters skip the instrumentation of requests if any of them returns
+	// false.
+	Filters []RequestFilter
+	// SpanNameFormatter names the server spans.
+	SpanNameFormatter SpanNameFormatterFn
+	// RequestEnrichers add attributes to the span when the request starts.
+	RequestEnrichers []RequestEnricher
+	// ResponseEnrichers add attributes to the span once the handler returns.
+	ResponseEnrichers []ResponseEnricher
+	// TracerProvider creates the server spans. It uses the one from the request
+	// context or the global one if nil.
+	TracerProvider trace.TracerProvider
+	// MeterProvider records the server metrics. It uses the global one if nil.
+	MeterProvider metric.MeterProvider
+	// Propagator extracts the remote span context. It uses the global one if
+	// nil.
+	Propagator propagation.TextMapPropagator
+	// DecodeRequestBody transparently decodes gzip and deflate request bodies,
+	// so the span reports both the compressed and decoded sizes.
+	DecodeRequestBody bool
+	// BaggagePolicy drops the inbound baggage members it rejects before the
+	// propagator extracts them. It keeps all members if nil.
+	BaggagePolicy BaggagePolicy
+	// TrustPolicy decides whether the remote span context is the parent of
+	// the server span or a link of a new trace. It trusts all requests if nil.
+	TrustPolicy TrustPolicy
+	// RouteExtractor returns the http.route of a request. It runs after the
+	// handler, so routers that match requests within it are supported.
+	RouteExtractor RouteExtractor
+	// RequestHeaders lists the request headers to record as span attributes.
+	RequestHeaders []string
+	// ResponseHeaders lists the response headers to record as span attributes.
+	ResponseHeaders []string
+	// RedactedHeaders lists the captured headers whose values are replaced by
+	// RedactedHeaderValue.
+	RedactedHeaders []string
+	// MetricAttributes lists the request and response attributes that apply to
+	// metrics. Context labeler attributes always apply.
+	MetricAttributes []attribute.Key
+	// FlightRecorder provides the execution trace snapshots of slow requests.
+	FlightRecorder FlightRecorder
+	// SlowRequestThreshold is the duration after which requests are slow.
+	SlowRequestThreshold time.Duration
+	// SlowRequestDump receives the snapshots of slow requests.
+	SlowRequestDump TraceDumpFn
+}
+
+// InstrumentationOption represents an object that can modify
+// InstrumentationOptions.
+type InstrumentationOption interface {
+	Apply(opts *InstrumentationOptions)
+}
+
+// InstrumentationOptionFn is a function that implements InstrumentationOption.
+type InstrumentationOptionFn func(opts *InstrumentationOptions)
+
+// Apply executes the function to apply its changes.
+func (fn InstrumentationOptionFn) Apply(opts *InstrumentationOptions) {
+	fn(opts)
+}
+
+// NewInstrumentationOptions creates a new InstrumentationOptions object with
+// defaults, then applies any InstrumentationOption to it.
+func NewInstrumentationOptions(options ...InstrumentationOption) *InstrumentationOptions {
+	//nolint:exhaustruct // nil providers and propagator use the global ones
+	opts := &InstrumentationOptions{
+		SpanNameFormatter: SpanNameFormatter,
+		RouteExtractor:    PatternRoute,
+		RequestHeaders:    capturedHeadersFromEnv(CaptureServerRequestHeadersEnv),
+		ResponseHeaders:   capturedHeadersFromEnv(CaptureServerResponseHeadersEnv),
+		RedactedHeaders:   DefaultRedactedHeaders(),
+		MetricAttributes:  ServerMetricAttributeKeys(),
+	}
+
+	for _, option := range options {
+		option.Apply(opts)
+	}
+
+	return opts
+}
+
+// WithRequestFilter skips the instrumentation of requests the filter rejects,
+// such as health checks. The handler still serves them.
+func WithRequestFilter(filter RequestFilter) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.Filters = append(opts.Filters, filter)
+	})
+}
+
+// WithSpanNameFormatter sets how server spans are named. The operation is the
+// request route, or empty if it's unknown.
+func WithSpanNameFormatter(formatter SpanNameFormatterFn) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.SpanNameFormatter = formatter
+	})
+}
+
+// WithRequestEnricher adds attributes to the span when the request starts.
+//
+// The attributes only apply to metrics if allowed by WithMetricAttributes.
+func WithRequestEnricher(enricher RequestEnricher) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.RequestEnrichers = append(opts.RequestEnrichers, enricher)
+	})
+}
+
+// WithResponseEnricher adds attributes to the span once the handler returns.
+//
+// The attributes only apply to metrics if allowed by WithMetricAttributes.
+func WithResponseEnricher(enricher ResponseEnricher) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.ResponseEnrichers = append(opts.ResponseEnrichers, enricher)
+	})
+}
+
+// WithTracerProvider sets the provider of the server spans.
+func WithTracerProvider(provider trace.TracerProvider) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.TracerProvider = provider
+	})
+}
+
+// WithMeterProvider sets the provider of the server metrics.
+func WithMeterProvider(provider metric.MeterProvider) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.MeterProvider = provider
+	})
+}
+
+// WithTextMapPropagator sets the propagator that extracts the remote span
+// context of requests.
+func WithTextMapPropagator(propagator propagation.TextMapPropagator) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.Propagator = propagator
+	})
+}
+
+// WithRequestBodyDecoding decodes gzip and deflate request bodies before the
+// handler reads them, removing the Content-Encoding header. Without it, spans
+// only report the compressed size, as handlers decode bodies on their own.
+func WithRequestBodyDecoding() InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.DecodeRequestBody = true
+	})
+}
+
+// WithTrustPolicy sets which requests continue the remote trace. Untrusted
+// ones start a new trace linked to the remote span context instead.
+func WithTrustPolicy(policy TrustPolicy) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.TrustPolicy = policy
+	})
+}
+
+// WithBaggagePolicy drops the inbound baggage members the policy rejects, and
+// malformed or oversized baggage headers, before extracting the request
+// context. The handler receives the filtered headers as well.
+func WithBaggagePolicy(policy BaggagePolicy) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.BaggagePolicy = policy
+	})
+}
+
+// WithPublicEndpoint starts a new trace for each request and links it to the
+// remote span context, if any. Use it on endpoints that receive requests from
+// untrusted clients, which could otherwise choose the trace ID and sampling.
+//
+// It is a shorthand for WithTrustPolicy(TrustNever()).
+func WithPublicEndpoint() InstrumentationOption {
+	return WithTrustPolicy(TrustNever())
+}
+
+// WithRouteExtractor sets how the instrumentation finds the route template of
+// a request. Use it with routers that don't set http.Request.Pattern.
+func WithRouteExtractor(extractor RouteExtractor) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.RouteExtractor = extractor
+	})
+}
+
+// WithRoute sets a static route template for all requests.
+func WithRoute(route string) InstrumentationOption {
+	return WithRouteExtractor(func(*http.Request) string {
+		return route
+	})
+}
+
+// WithSlowRequestTrace dumps a snapshot of the flight recorder once a request
+// takes longer than the threshold, so the execution trace shows what it was
+// waiting on. Snapshots run in the background, one at a time; slow requests
+// that end meanwhile don't trigger another.
+//
+// See WithRuntimeTrace to label the execution trace with spans.
+func WithSlowRequestTrace(recorder FlightRecorder, threshold time.Duration, dump TraceDumpFn) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.FlightRecorder = recorder
+		opts.SlowRequestThreshold = threshold
+		opts.SlowRequestDump = dump
+	})
+}
+
+// WithRequestHeaders captures the request headers as span attributes, in
+// addition to the ones from the
+// OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_REQUEST variable.
+func WithRequestHeaders(names ...string) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.RequestHeaders = append(opts.RequestHeaders, names...)
+	})
+}
+
+// WithResponseHeaders captures the response headers as span attributes, in
+// addition to the ones from the
+// OTEL_INSTRUMENTATION_HTTP_CAPTURE_HEADERS_SERVER_RESPONSE variable.
+func WithResponseHeaders(names ...string) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.ResponseHeaders = append(opts.ResponseHeaders, names...)
+	})
+}
+
+// WithRedactedHeaders redacts the values of the headers, in addition to the
+// DefaultRedactedHeaders.
+func WithRedactedHeaders(names ...string) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.RedactedHeaders = append(opts.RedactedHeaders, names...)
+	})
+}
+
+// WithMetricAttributes allows more request and response attributes on metrics,
+// in addition to the ServerMetricAttributeKeys. Beware that each distinct value
+// creates a new time series.
+func WithMetricAttributes(keys ...attribute.Key) InstrumentationOption {
+	return InstrumentationOptionFn(func(opts *InstrumentationOptions) {
+		opts.MetricAttributes = append(opts.MetricAttributes, keys...)
+	})
+}
+
+// ServerMetricAttributeKeys returns the low-cardinality attributes that the
+// semantic conventions recommend for HTTP server metrics, in both the current
+// and deprecated forms.
+//
+// It excludes the opt-in server address and port, as they come from the Host
+// header that clients control. Use WithMetricAttributes to add them.
+//
+// See https://opentelemetry.io/docs/specs/semconv/http/http-metrics/#http-server
+func ServerMetricAttributeKeys() []attribute.Key {
+	return []attribute.Key{
+		errorTypeKey,
+		"http.request.method",
+		"http.response.status_code",
+		"http.route",
+		"network.protocol.name",
+		"network.protocol.version",
+		"url.scheme",
+		// deprecated
+		"http.method",
+		"http.scheme",
+		"http.status_code",
+	}
+}
+
+// activeRequestsAttributeKeys returns the attributes known when a request
+// starts, which http.server.active_requests uses to pair its increments and
+// decrements.
+func activeRequestsAttributeKeys() []attribute.Key {
+	return []attribute.Key{
+		"http.request.method",
+		"url.scheme",
+		// deprecated
+		"http.method",
+		"http.scheme",
+	}
+}
+
+// metricAttributeFilter only keeps attributes with the allowed keys.
+func metricAttributeFilter(keys []attribute.Key) attribute.Filter {
+	allowed := make(map[attribute.Key]struct{}, len(keys))
+
+	for _, key := range keys {
+		allowed[key] = struct{}{}
+	}
+
+	return func(kv attribute.KeyValue) bool {
+		_, ok := allowed[kv.Key]
+
+		return ok
+	}
+}
+
+// PatternRoute returns the path of the http.ServeMux pattern that matched the
+// request, without its method and host parts.
+func PatternRoute(req *http.Request) string {
+	return patternRoute(req.Pattern)
+}
+
+// patternRoute extracts the path out of a [METHOD ][HOST]/[PATH] pattern.
+func patternRoute(pattern string) string {
+	if _, path, found := strings.Cut(pattern, " "); found {
+		pattern = strings.TrimLeft(path, " \t")
+	}
+
+	index := strings.IndexByte(pattern, '/')
+	if index < 0 {
+		return ""
+	}
+
+	return pattern[index:]
+}
+
+func filterAttributes(attrs []attribute.KeyValue, filter attribute.Filter) []attribute.KeyValue {
+	filtered := make([]attribute.KeyValue, 0, len(attrs))
+
+	for _, kv := range attrs {
+		if filter(kv) {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	return filtered
+}
+
+// accepts reports whether all filters accept the request.
+func (opts *InstrumentationOptions) accepts(req *http.Request) bool {
+	for _, filter := range opts.Filters {
+		if !filter(req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trusts reports whether the remote span context of the request can be the
+// parent of the server span.
+func (opts *InstrumentationOptions) trusts(req *http.Request) bool {
+	return opts.TrustPolicy == nil || opts.TrustPolicy(req)
+}
+
+func (opts *InstrumentationOptions) tracer(ctx context.Context) trace.Tracer {
+	if opts.TracerProvider == nil {
+		return Tracer(ctx)
+	}
+
+	return opts.TracerProvider.Tracer(NAME)
+}
+
+func (opts *InstrumentationOptions) propagator() propagation.TextMapPropagator {
+	if opts.Propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+
+	return opts.Propagator
+}
+
+// slowRequestTracer returns the tracer of slow requests, or nil if disabled.
+func (opts *InstrumentationOptions) slowRequestTracer() *slowRequestTracer {
+	if opts.FlightRecorder == nil || opts.SlowRequestDump == nil {
+		return nil
+	}
+
+	//nolint:exhaustruct // not running yet
+	return &slowRequestTracer{
+		recorder:  opts.FlightRecorder,
+		threshold: opts.SlowRequestThreshold,
+		dump:      opts.SlowRequestDump,
+	}
+}
+
+func (opts *InstrumentationOptions) instruments() *serverInstruments {
+	if opts.MeterProvider == nil {
+		return httpServerInstruments()
+	}
+
+	return newServerInstruments(opts.MeterProvider.Meter(NAME))
+}
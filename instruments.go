@@ -56,10 +56,10 @@ var (
 		return instrument
 	})
 
-	httpServerActiveRequestsInstrument = sync.OnceValue(func() metric.Int64Gauge {
-		instrument, err := Meter().Int64Gauge(
-			"http.server.active_requests",
-			metric.WithDescription("Number of active HTTP server requests."),
+	httpClientActiveRequests = sync.OnceValue(func() metric.Int64UpDownCounter {
+		instrument, err := Meter().Int64UpDownCounter(
+			"http.client.active_requests",
+			metric.WithDescription("Number of active HTTP requests."),
 			metric.WithUnit("{request}"),
 		)
 		if err != nil {
@@ -69,11 +69,11 @@ var (
 		return instrument
 	})
 
-	httpServerRequestBodySizeInstrument = sync.OnceValue(func() metric.Float64Histogram {
-		instrument, err := Meter().Float64Histogram(
-			"http.server.request.body.size",
-			metric.WithDescription("Size of HTTP server request bodies."),
-			metric.WithUnit(ucumBytes),
+	httpClientOpenConnections = sync.OnceValue(func() metric.Int64UpDownCounter {
+		instrument, err := Meter().Int64UpDownCounter(
+			"http.client.open_connections",
+			metric.WithDescription("Number of outbound HTTP connections that are currently active or idle on the client."),
+			metric.WithUnit("{connection}"),
 		)
 		if err != nil {
 			otel.Handle(err)
@@ -82,13 +82,13 @@ var (
 		return instrument
 	})
 
-	httpServerRequestDurationInstrument = sync.OnceValue(func() metric.Float64Histogram {
+	httpClientConnectionDuration = sync.OnceValue(func() metric.Float64Histogram {
 		instrument, err := Meter().Float64Histogram(
-			"http.server.request.duration",
-			metric.WithDescription("Duration of HTTP server requests."),
+			"http.client.connection.duration",
+			metric.WithDescription("The duration of the successfully established outbound HTTP connections."),
 			metric.WithUnit(ucumSeconds),
 			//nolint:mnd // as per https://opentelemetry.io/docs/specs/semconv/http/http-metrics/
-			metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+			metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 30, 60, 120, 300),
 		)
 		if err != nil {
 			otel.Handle(err)
@@ -97,16 +97,75 @@ var (
 		return instrument
 	})
 
-	httpServerResponseBodySizeInstrument = sync.OnceValue(func() metric.Float64Histogram {
-		instrument, err := Meter().Float64Histogram(
-			"http.server.response.body.size",
-			metric.WithDescription("Size of HTTP server response bodies."),
-			metric.WithUnit(ucumBytes),
-		)
-		if err != nil {
-			otel.Handle(err)
-		}
-
-		return instrument
+	httpServerInstruments = sync.OnceValue(func() *serverInstruments {
+		return newServerInstruments(Meter())
 	})
 )
+
+// serverInstruments groups the HTTP server metrics of a meter.
+type serverInstruments struct {
+	activeRequests   metric.Int64UpDownCounter
+	requestBodySize  metric.Float64Histogram
+	requestDuration  metric.Float64Histogram
+	responseBodySize metric.Float64Histogram
+	// rejectedTraceContexts counts remote span contexts that didn't become the
+	// parent of the server span.
+	rejectedTraceContexts metric.Int64Counter
+}
+
+func newServerInstruments(meter metric.Meter) *serverInstruments {
+	activeRequests, err := meter.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithDescription("Number of active HTTP server requests."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	requestBodySize, err := meter.Float64Histogram(
+		"http.server.request.body.size",
+		metric.WithDescription("Size of HTTP server request bodies."),
+		metric.WithUnit(ucumBytes),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	requestDuration, err := meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit(ucumSeconds),
+		//nolint:mnd // as per https://opentelemetry.io/docs/specs/semconv/http/http-metrics/
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	responseBodySize, err := meter.Float64Histogram(
+		"http.server.response.body.size",
+		metric.WithDescription("Size of HTTP server response bodies."),
+		metric.WithUnit(ucumBytes),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	rejectedTraceContexts, err := meter.Int64Counter(
+		"gotell.http.server.trace_context.rejected",
+		metric.WithDescription("Number of malformed or untrusted remote trace contexts of HTTP server requests."),
+		metric.WithUnit("{trace_context}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &serverInstruments{
+		activeRequests:        activeRequests,
+		requestBodySize:       requestBodySize,
+		requestDuration:       requestDuration,
+		responseBodySize:      responseBodySize,
+		rejectedTraceContexts: rejectedTraceContexts,
+	}
+}
@@ -0,0 +1,176 @@
+package gotell
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+)
+
+// TelemetrySignal names one of the OpenTelemetry signals.
+type TelemetrySignal string
+
+const (
+	// SignalTraces identifies the tracer provider.
+	SignalTraces TelemetrySignal = "traces"
+	// SignalLogs identifies the logger provider.
+	SignalLogs TelemetrySignal = "logs"
+	// SignalMetrics identifies the meter provider.
+	SignalMetrics TelemetrySignal = "metrics"
+)
+
+// ProviderError tags an error with the signal whose provider returned it.
+type ProviderError struct {
+	Signal TelemetrySignal
+	Err    error
+}
+
+// Error returns the signal name followed by the underlying error message.
+func (err *ProviderError) Error() string {
+	return string(err.Signal) + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ProviderError) Unwrap() error {
+	return err.Err
+}
+
+// ComponentError tags an error with the name of the registered component that
+// returned it.
+type ComponentError struct {
+	Name string
+	Err  error
+}
+
+// Error returns the component name followed by the underlying error message.
+func (err *ComponentError) Error() string {
+	return err.Name + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ComponentError) Unwrap() error {
+	return err.Err
+}
+
+// LifecycleOptions contains properties that customize Shutdown and ForceFlush.
+type LifecycleOptions struct {
+	// Timeouts bounds each signal individually. Signals without an entry use
+	// only the deadline of the parent context.
+	Timeouts map[TelemetrySignal]time.Duration
+}
+
+// LifecycleOption represents an object that can modify LifecycleOptions.
+type LifecycleOption interface {
+	Apply(opts *LifecycleOptions)
+}
+
+// LifecycleOptionFn is a function that implements LifecycleOption.
+type LifecycleOptionFn func(opts *LifecycleOptions)
+
+// Apply executes the function to apply its changes.
+func (fn LifecycleOptionFn) Apply(opts *LifecycleOptions) {
+	fn(opts)
+}
+
+// WithSignalTimeout bounds the time a single signal provider has to shut down
+// or flush. It doesn't extend the deadline of the parent context.
+func WithSignalTimeout(signal TelemetrySignal, timeout time.Duration) LifecycleOption {
+	return LifecycleOptionFn(func(opts *LifecycleOptions) {
+		opts.Timeouts[signal] = timeout
+	})
+}
+
+func newLifecycleOptions(options ...LifecycleOption) *LifecycleOptions {
+	opts := &LifecycleOptions{
+		Timeouts: map[TelemetrySignal]time.Duration{},
+	}
+
+	for _, option := range options {
+		option.Apply(opts)
+	}
+
+	return opts
+}
+
+// lifecycleEntry is a target of Shutdown or ForceFlush. Entries with a signal
+// are telemetry providers; the others are user components.
+type lifecycleEntry struct {
+	name   string
+	signal TelemetrySignal
+	target any
+}
+
+func (entry *lifecycleEntry) wrap(err error) error {
+	if entry.signal != "" {
+		return &ProviderError{Signal: entry.signal, Err: err}
+	}
+
+	return &ComponentError{Name: entry.name, Err: err}
+}
+
+// signalOrder lists the signals in the order their providers stop.
+//
+//nolint:gochecknoglobals // read-only
+var signalOrder = []TelemetrySignal{SignalTraces, SignalLogs, SignalMetrics}
+
+// globalProviderEntries lists the global providers in the order they must
+// stop: traces first, as ending spans may still emit logs and metrics; then
+// logs; and metrics last so they account for everything else.
+func globalProviderEntries() []lifecycleEntry {
+	return []lifecycleEntry{
+		{name: string(SignalTraces), signal: SignalTraces, target: otel.GetTracerProvider()},
+		{name: string(SignalLogs), signal: SignalLogs, target: global.GetLoggerProvider()},
+		{name: string(SignalMetrics), signal: SignalMetrics, target: otel.GetMeterProvider()},
+	}
+}
+
+// withGlobalProviders appends the global providers that aren't part of entries
+// already.
+func withGlobalProviders(entries []lifecycleEntry) []lifecycleEntry {
+	for _, provider := range globalProviderEntries() {
+		if !slices.ContainsFunc(entries, func(entry lifecycleEntry) bool {
+			return entry.target == provider.target
+		}) {
+			entries = append(entries, provider)
+		}
+	}
+
+	return entries
+}
+
+// runLifecycle calls fn sequentially for each entry. Providers are bounded by
+// their respective signal timeouts. It joins all errors, tagged by signal or
+// component name, ignoring errors.ErrUnsupported ones.
+func runLifecycle(
+	ctx context.Context,
+	opts *LifecycleOptions,
+	entries []lifecycleEntry,
+	fn func(ctx context.Context, target any) error,
+) error {
+	var errs []error
+
+	for _, entry := range entries {
+		err := runStage(ctx, opts.Timeouts[entry.signal], func(ctx context.Context) error {
+			return fn(ctx, entry.target)
+		})
+		if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			errs = append(errs, entry.wrap(err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func runStage(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
+}
@@ -0,0 +1,172 @@
+package gotell_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var (
+	errTracesProvider  = errors.New("traces provider failed")
+	errMetricsProvider = errors.New("metrics provider failed")
+)
+
+// fakeProvider records its calls as method:name, and whether their context has
+// a deadline, if deadlines is set. It then fails with err or, if blocking,
+// waits for the context to end.
+type fakeProvider struct {
+	name      string
+	calls     *[]string
+	deadlines map[string]bool
+	err       error
+	block     bool
+}
+
+func (provider *fakeProvider) Shutdown(ctx context.Context) error {
+	return provider.call(ctx, "shutdown")
+}
+
+func (provider *fakeProvider) ForceFlush(ctx context.Context) error {
+	return provider.call(ctx, "flush")
+}
+
+func (provider *fakeProvider) call(ctx context.Context, method string) error {
+	*provider.calls = append(*provider.calls, method+":"+provider.name)
+
+	if provider.deadlines != nil {
+		_, provider.deadlines[provider.name] = ctx.Deadline()
+	}
+
+	if provider.block {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	return provider.err
+}
+
+// registerLifecycleProviders registers a provider per signal, named after it,
+// in the reverse order they must run, and returns their calls and whether each
+// got a deadline.
+func registerLifecycleProviders(
+	errs map[gotell.TelemetrySignal]error,
+	blocking gotell.TelemetrySignal,
+) (*[]string, map[string]bool) {
+	calls := &[]string{}
+	deadlines := map[string]bool{}
+
+	for _, signal := range []gotell.TelemetrySignal{gotell.SignalMetrics, gotell.SignalLogs, gotell.SignalTraces} {
+		gotell.RegisterProvider(signal, &fakeProvider{
+			name:      string(signal),
+			calls:     calls,
+			deadlines: deadlines,
+			err:       errs[signal],
+			block:     signal == blocking,
+		})
+	}
+
+	return calls, deadlines
+}
+
+//nolint:paralleltest // mutates the global registry
+func TestLifecycleOrder(t *testing.T) {
+	calls, _ := registerLifecycleProviders(nil, "")
+
+	require.NoError(t, gotell.ForceFlush(context.Background()))
+	require.NoError(t, gotell.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{
+		"flush:traces",
+		"flush:logs",
+		"flush:metrics",
+		"shutdown:traces",
+		"shutdown:logs",
+		"shutdown:metrics",
+	}, *calls)
+}
+
+//nolint:paralleltest // mutates the global registry
+func TestLifecycleSignalTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(ctx context.Context, options ...gotell.LifecycleOption) error
+		wantErr error
+	}{
+		{"flush", gotell.ForceFlush, gotell.ErrForceFlush},
+		{"shutdown", gotell.Shutdown, gotell.ErrShutdownFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, deadlines := registerLifecycleProviders(nil, gotell.SignalLogs)
+			timeout := gotell.WithSignalTimeout(gotell.SignalLogs, 10*time.Millisecond)
+
+			// forgets the blocking provider after ForceFlush
+			t.Cleanup(func() { _ = gotell.Shutdown(context.Background(), timeout) })
+
+			started := time.Now()
+
+			err := tt.run(context.Background(), timeout)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(started), time.Second)
+
+			var providerErr *gotell.ProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, gotell.SignalLogs, providerErr.Signal)
+
+			// the blocked signal doesn't stop the next ones, nor bounds them
+			assert.Len(t, *calls, 3)
+			assert.Equal(t, map[string]bool{
+				"traces":  false,
+				"logs":    true,
+				"metrics": false,
+			}, deadlines)
+		})
+	}
+}
+
+//nolint:paralleltest // mutates the global registry
+func TestLifecycleJoinedErrors(t *testing.T) {
+	calls, _ := registerLifecycleProviders(map[gotell.TelemetrySignal]error{
+		gotell.SignalTraces:  errTracesProvider,
+		gotell.SignalMetrics: errMetricsProvider,
+	}, "")
+
+	err := gotell.Shutdown(context.Background())
+	require.ErrorIs(t, err, gotell.ErrShutdownFailed)
+	require.ErrorIs(t, err, errTracesProvider)
+	require.ErrorIs(t, err, errMetricsProvider)
+
+	assert.Len(t, *calls, 3)
+	assert.Equal(t, []gotell.TelemetrySignal{gotell.SignalTraces, gotell.SignalMetrics}, providerErrorSignals(err))
+	assert.Contains(t, err.Error(), "traces: "+errTracesProvider.Error())
+	assert.Contains(t, err.Error(), "metrics: "+errMetricsProvider.Error())
+}
+
+// providerErrorSignals returns the signals of the ProviderError values within
+// the errors.Join tree of err, in order.
+func providerErrorSignals(err error) []gotell.TelemetrySignal {
+	//nolint:errorlint // walks the tree itself
+	switch err := err.(type) {
+	case *gotell.ProviderError:
+		return []gotell.TelemetrySignal{err.Signal}
+	case interface{ Unwrap() []error }:
+		var signals []gotell.TelemetrySignal
+
+		for _, err := range err.Unwrap() {
+			signals = append(signals, providerErrorSignals(err)...)
+		}
+
+		return signals
+	default:
+		return nil
+	}
+}
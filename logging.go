@@ -17,22 +17,27 @@ import (
 // through the standard logr.NewContext.
 //
 // It'll return a standard logr.Logger with an OpenTelemetry sink if there's no
-// default set.
+// default set, or if it discards everything, such as logr.Discard.
 func Logr(ctx context.Context) logr.Logger {
+	//nolint:exhaustruct // call depth is set by Init
 	sink := &logging.OpenTelemetryLogSink{
 		Context:    ctx,
 		Logger:     Logger(),
 		Name:       "",
 		Attributes: []log.KeyValue{},
+		Source:     logSource,
 	}
 
 	logger, err := logr.FromContext(ctx)
-	if err != nil {
+	if err != nil || logger.GetSink() == nil {
 		return logr.New(sink)
 	}
 
 	originalSink := logger.GetSink()
 
+	// logr only initializes sinks on logr.New; the tee adds a call frame
+	sink.Init(logr.RuntimeInfo{CallDepth: 2}) //nolint:mnd // logr plus tee frames
+
 	if teeSink, ok := originalSink.(logging.TeeLogSink); ok {
 		return logger.WithSink(append(teeSink, sink))
 	}
@@ -55,6 +60,20 @@ func Slog() *slog.Logger {
 			Logger: Logger(),
 			Group:  "",
 			Attrs:  []slog.Attr{},
+			Source: logSource,
 		},
 	})
 }
+
+// logSource converts a logging call site into code attributes, following the
+// same semantic conventions mode as spans.
+func logSource(pc uintptr) []log.KeyValue {
+	attrs := callerInfoCache.load(pc, resolveCallerInfo).attributes[codeSemconv.get()-1]
+
+	values := make([]log.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		values = append(values, log.KeyValueFromAttribute(attr))
+	}
+
+	return values
+}
@@ -16,6 +16,9 @@ type OpenTelemetryHandler struct {
 	Logger log.Logger
 	Group  string
 	Attrs  []slog.Attr
+	// Source converts the program counter of the logging call site into source
+	// location attributes. It's optional.
+	Source SourceFn
 }
 
 // Enabled returns true if the OpenTelemetry logger emits records for this
@@ -49,6 +52,10 @@ func (handler *OpenTelemetryHandler) Handle(ctx context.Context, rec slog.Record
 
 	otelRecord.AddAttributes(keyValues...)
 
+	if handler.Source != nil && rec.PC != 0 {
+		otelRecord.AddAttributes(handler.Source(rec.PC)...)
+	}
+
 	handler.Logger.Emit(ctx, otelRecord)
 
 	return nil
@@ -65,6 +72,7 @@ func (handler *OpenTelemetryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		Logger: handler.Logger,
 		Group:  handler.Group,
 		Attrs:  newAttrs,
+		Source: handler.Source,
 	}
 }
 
@@ -78,5 +86,6 @@ func (handler *OpenTelemetryHandler) WithGroup(name string) slog.Handler {
 		Logger: handler.Logger,
 		Group:  path.Join(handler.Group, name),
 		Attrs:  attrs,
+		Source: handler.Source,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"runtime"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -19,6 +20,11 @@ type OpenTelemetryLogSink struct {
 	Logger     log.Logger
 	Name       string
 	Attributes []log.KeyValue
+	// Source converts the program counter of the logging call site into source
+	// location attributes. It's optional.
+	Source SourceFn
+
+	callDepth int
 }
 
 // Enabled always returns true.
@@ -26,15 +32,21 @@ func (*OpenTelemetryLogSink) Enabled(_ int) bool {
 	return true
 }
 
-// Error emits an OpenTelemetry log entry with an error severity.
+// Error emits an OpenTelemetry log entry with an error severity. The error, if
+// any, follows the message in the body.
 func (sink *OpenTelemetryLogSink) Error(err error, msg string, keysAndValues ...any) {
 	record := log.Record{}
 
+	if err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, err.Error())
+	}
+
 	record.SetTimestamp(time.Now())
-	record.SetBody(log.StringValue(fmt.Sprintf("%s: %s", msg, err.Error())))
+	record.SetBody(log.StringValue(msg))
 	record.SetSeverity(log.SeverityError)
 	record.AddAttributes(kv2akv(keysAndValues...)...)
 	record.AddAttributes(log.String("log.name", sink.Name))
+	sink.addSource(&record)
 
 	sink.Logger.Emit(sink.Context, record)
 }
@@ -48,12 +60,15 @@ func (sink *OpenTelemetryLogSink) Info(_ int, msg string, keysAndValues ...any)
 	record.SetSeverity(log.SeverityInfo)
 	record.AddAttributes(kv2akv(keysAndValues...)...)
 	record.AddAttributes(log.String("log.name", sink.Name))
+	sink.addSource(&record)
 
 	sink.Logger.Emit(sink.Context, record)
 }
 
-// Init does nothing. It exists to satisfy the logr.LogSink interface.
-func (*OpenTelemetryLogSink) Init(_ logr.RuntimeInfo) {}
+// Init stores the call depth logr adds, to find the logging call site.
+func (sink *OpenTelemetryLogSink) Init(info logr.RuntimeInfo) {
+	sink.callDepth = info.CallDepth
+}
 
 // WithName returns a new OpenTelemetrySink with the specified name appended.
 func (sink *OpenTelemetryLogSink) WithName(name string) logr.LogSink {
@@ -66,6 +81,8 @@ func (sink *OpenTelemetryLogSink) WithName(name string) logr.LogSink {
 		Logger:     sink.Logger,
 		Name:       path.Join(sink.Name, name),
 		Attributes: values,
+		Source:     sink.Source,
+		callDepth:  sink.callDepth,
 	}
 }
 
@@ -83,5 +100,22 @@ func (sink *OpenTelemetryLogSink) WithValues(keysAndValues ...any) logr.LogSink
 		Logger:     sink.Logger,
 		Name:       sink.Name,
 		Attributes: values,
+		Source:     sink.Source,
+		callDepth:  sink.callDepth,
+	}
+}
+
+// addSource adds the source location of the logging call site. It must be
+// called directly by the LogSink methods.
+func (sink *OpenTelemetryLogSink) addSource(record *log.Record) {
+	if sink.Source == nil {
+		return
+	}
+
+	var pcs [1]uintptr
+
+	// skips runtime.Callers, addSource and the LogSink method
+	if runtime.Callers(3+sink.callDepth, pcs[:]) > 0 {
+		record.AddAttributes(sink.Source(pcs[0])...)
 	}
 }
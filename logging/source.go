@@ -0,0 +1,7 @@
+package logging
+
+import "go.opentelemetry.io/otel/log"
+
+// SourceFn converts the program counter of a logging call site into source
+// location attributes.
+type SourceFn func(pc uintptr) []log.KeyValue
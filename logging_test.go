@@ -0,0 +1,174 @@
+package gotell_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"github.com/wwmoraes/gotell"
+)
+
+// recordingLogProcessor keeps the emitted records in memory.
+type recordingLogProcessor struct {
+	mutex   sync.Mutex
+	records []sdklog.Record
+}
+
+func (processor *recordingLogProcessor) OnEmit(_ context.Context, record *sdklog.Record) error {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	processor.records = append(processor.records, record.Clone())
+
+	return nil
+}
+
+func (*recordingLogProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (*recordingLogProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+// last returns the attributes of the last record.
+func (processor *recordingLogProcessor) last(t *testing.T) map[string]log.Value {
+	t.Helper()
+
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	require.NotEmpty(t, processor.records)
+
+	attrs := map[string]log.Value{}
+
+	processor.records[len(processor.records)-1].WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+
+		return true
+	})
+
+	return attrs
+}
+
+// callerLine returns the line of its caller, to locate the logging call on
+// the next line.
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+
+	return line
+}
+
+//nolint:paralleltest // sets the global logger provider and code semconv
+func TestLoggingSource(t *testing.T) {
+	processor := &recordingLogProcessor{mutex: sync.Mutex{}, records: nil}
+
+	previous := global.GetLoggerProvider()
+	global.SetLoggerProvider(sdklog.NewLoggerProvider(sdklog.WithProcessor(processor)))
+	gotell.SetCodeSemconv(gotell.SemconvNew)
+
+	t.Cleanup(func() {
+		global.SetLoggerProvider(previous)
+		gotell.SetCodeSemconv(gotell.SemconvFromEnv)
+	})
+
+	const function = "github.com/wwmoraes/gotell_test.TestLoggingSource"
+
+	tests := []struct {
+		name string
+		log  func() int
+	}{
+		{
+			name: "logr",
+			log: func() int {
+				logger := gotell.Logr(context.Background())
+
+				line := callerLine() + 1
+				logger.Info("message")
+
+				return line
+			},
+		},
+		{
+			name: "logr discard",
+			log: func() int {
+				ctx := logr.NewContext(context.Background(), logr.Discard())
+				logger := gotell.Logr(ctx)
+
+				line := callerLine() + 1
+				logger.Error(nil, "message")
+
+				return line
+			},
+		},
+		{
+			name: "logr tee",
+			log: func() int {
+				ctx := logr.NewContext(context.Background(), logr.FromSlogHandler(slog.NewTextHandler(io.Discard, nil)))
+				logger := gotell.Logr(ctx)
+
+				line := callerLine() + 1
+				logger.Info("message")
+
+				return line
+			},
+		},
+		{
+			name: "logr tee nested",
+			log: func() int {
+				base := logr.FromSlogHandler(slog.NewTextHandler(io.Discard, nil))
+				ctx := logr.NewContext(context.Background(), gotell.Logr(logr.NewContext(context.Background(), base)))
+				logger := gotell.Logr(ctx).WithName("nested").WithValues("key", "value")
+
+				line := callerLine() + 1
+				logger.Info("message")
+
+				return line
+			},
+		},
+		{
+			name: "slog",
+			log: func() int {
+				logger := gotell.Slog()
+
+				line := callerLine() + 1
+				logger.InfoContext(context.Background(), "message")
+
+				return line
+			},
+		},
+		{
+			name: "slog group",
+			log: func() int {
+				logger := gotell.Slog().WithGroup("group").With(slog.String("key", "value"))
+
+				line := callerLine() + 1
+				logger.Warn("message")
+
+				return line
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.log()
+
+			attrs := processor.last(t)
+
+			assert.Equal(t, int64(line), attrs["code.line.number"].AsInt64())
+			assert.Contains(t, attrs["code.function.name"].AsString(), function)
+			assert.Contains(t, attrs["code.file.path"].AsString(), "logging_test.go")
+		})
+	}
+}
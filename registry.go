@@ -0,0 +1,121 @@
+package gotell
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+)
+
+//nolint:gochecknoglobals // mirrors the global providers lifecycle
+var (
+	shutdownRegistry = &lifecycleRegistry{}
+	flushRegistry    = &lifecycleRegistry{}
+	providerRegistry = &lifecycleRegistry{}
+)
+
+// RegisterShutdowner adds a component to the ones Shutdown stops, such as HTTP
+// servers, database pools or custom exporters. The name tags its errors.
+//
+// Shutdown stops registered components in reverse registration order, then
+// forgets them. They always stop before the telemetry providers, even if
+// registered before Initialize. The returned function removes the
+// component in case the caller stops it on its own.
+func RegisterShutdowner(name string, shutdowner Shutdowner) func() {
+	return shutdownRegistry.add(lifecycleEntry{name: name, signal: "", target: shutdowner})
+}
+
+// RegisterFlusher adds a component to the ones ForceFlush flushes. The name
+// tags its errors.
+//
+// ForceFlush flushes registered components in reverse registration order,
+// always before the telemetry providers. Shutdown doesn't forget them, so use the
+// returned function to remove the component once it stops.
+func RegisterFlusher(name string, flusher ForceFlusher) func() {
+	return flushRegistry.add(lifecycleEntry{name: name, signal: "", target: flusher})
+}
+
+// ShutdownFn is a function that implements Shutdowner.
+type ShutdownFn func() error
+
+// Shutdown calls the function, ignoring the context. It adapts Close methods,
+// such as the one from *sql.DB.
+func (fn ShutdownFn) Shutdown(_ context.Context) error {
+	return fn()
+}
+
+// registerProvider tracks a provider created by gotell for both Shutdown and
+// ForceFlush, until the next Shutdown.
+func registerProvider(signal TelemetrySignal, provider any) {
+	providerRegistry.add(lifecycleEntry{name: string(signal), signal: signal, target: provider})
+}
+
+// providerEntries returns the providers created by gotell grouped by signal, in
+// the order globalProviderEntries stops them, newest first within each signal.
+// It also forgets them if reset is set.
+func providerEntries(reset bool) []lifecycleEntry {
+	entries := providerRegistry.snapshot(reset)
+
+	slices.SortStableFunc(entries, func(a, b lifecycleEntry) int {
+		return cmp.Compare(slices.Index(signalOrder, a.signal), slices.Index(signalOrder, b.signal))
+	})
+
+	return entries
+}
+
+type lifecycleRegistry struct {
+	mutex   sync.Mutex
+	nextID  uint64
+	entries map[uint64]lifecycleEntry
+	order   []uint64
+}
+
+func (registry *lifecycleRegistry) add(entry lifecycleEntry) func() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if registry.entries == nil {
+		registry.entries = map[uint64]lifecycleEntry{}
+	}
+
+	id := registry.nextID
+	registry.nextID++
+
+	registry.entries[id] = entry
+	registry.order = append(registry.order, id)
+
+	return func() {
+		registry.remove(id)
+	}
+}
+
+func (registry *lifecycleRegistry) remove(id uint64) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.entries, id)
+
+	registry.order = slices.DeleteFunc(registry.order, func(entryID uint64) bool {
+		return entryID == id
+	})
+}
+
+// snapshot returns the entries in reverse registration order. It also clears
+// the registry if reset is set.
+func (registry *lifecycleRegistry) snapshot(reset bool) []lifecycleEntry {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	entries := make([]lifecycleEntry, 0, len(registry.order))
+
+	for _, id := range slices.Backward(registry.order) {
+		entries = append(entries, registry.entries[id])
+	}
+
+	if reset {
+		registry.entries = nil
+		registry.order = nil
+	}
+
+	return entries
+}
@@ -0,0 +1,103 @@
+package gotell_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var errComponent = errors.New("component failed")
+
+//nolint:paralleltest // mutates the global registry
+func TestRegisterShutdowner(t *testing.T) {
+	var order []string
+
+	register := func(name string, err error) func() {
+		return gotell.RegisterShutdowner(name, gotell.ShutdownFn(func() error {
+			order = append(order, name)
+
+			return err
+		}))
+	}
+
+	register("first", nil)
+	register("second", errComponent)
+	unregister := register("removed", nil)
+	register("third", nil)
+
+	unregister()
+
+	err := gotell.Shutdown(context.Background())
+	require.ErrorIs(t, err, gotell.ErrShutdownFailed)
+	require.ErrorIs(t, err, errComponent)
+
+	var componentErr *gotell.ComponentError
+	require.ErrorAs(t, err, &componentErr)
+	assert.Equal(t, "second", componentErr.Name)
+
+	assert.Equal(t, []string{"third", "second", "first"}, order)
+
+	// shutdown forgets the components
+	require.NoError(t, gotell.Shutdown(context.Background()))
+	assert.Len(t, order, 3)
+}
+
+//nolint:paralleltest // mutates the global registry
+func TestShutdownForgetsProviders(t *testing.T) {
+	var order []string
+
+	gotell.RegisterProvider(gotell.SignalTraces, &fakeProvider{name: "traces", calls: &order})
+
+	require.NoError(t, gotell.ForceFlush(context.Background()))
+	require.NoError(t, gotell.Shutdown(context.Background()))
+	require.NoError(t, gotell.ForceFlush(context.Background()))
+	require.NoError(t, gotell.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"flush:traces", "shutdown:traces"}, order)
+}
+
+//nolint:paralleltest // mutates the global registry
+func TestShutdownOrder(t *testing.T) {
+	var order []string
+
+	provider := func(signal gotell.TelemetrySignal, generation string) {
+		gotell.RegisterProvider(signal, &fakeProvider{name: string(signal) + generation, calls: &order})
+	}
+
+	component := func(name string) {
+		gotell.RegisterShutdowner(name, gotell.ShutdownFn(func() error {
+			order = append(order, "shutdown:"+name)
+
+			return nil
+		}))
+	}
+
+	// components registered before and after the providers, which an
+	// Initialize call registers again
+	component("before")
+	provider(gotell.SignalTraces, "1")
+	provider(gotell.SignalLogs, "1")
+	provider(gotell.SignalMetrics, "1")
+	component("after")
+	provider(gotell.SignalTraces, "2")
+	provider(gotell.SignalLogs, "2")
+	provider(gotell.SignalMetrics, "2")
+
+	require.NoError(t, gotell.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{
+		"shutdown:after",
+		"shutdown:before",
+		"shutdown:traces2",
+		"shutdown:traces1",
+		"shutdown:logs2",
+		"shutdown:logs1",
+		"shutdown:metrics2",
+		"shutdown:metrics1",
+	}, order)
+}
@@ -0,0 +1,181 @@
+package gotell
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	requestBodyReadDurationKey = "gotell.http.request.body.read_duration"
+	requestBodyEarlyCloseKey   = "gotell.http.request.body.early_close"
+	requestBodyDecodedSizeKey  = "gotell.http.request.body.decoded_size"
+)
+
+// ErrUnsupportedContentEncoding is returned when reading a request body whose
+// encoding gotell can't decode.
+var ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+
+// countingBody counts the bytes read from a body and how long the reads take.
+type countingBody struct {
+	io.ReadCloser
+
+	size     int64
+	duration time.Duration
+	eof      bool
+}
+
+func (body *countingBody) Read(data []byte) (int, error) {
+	start := time.Now()
+	n, err := body.ReadCloser.Read(data)
+	body.duration += time.Since(start)
+	body.size += int64(n)
+
+	if errors.Is(err, io.EOF) {
+		body.eof = true
+	}
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return n, err
+}
+
+// decodingBody decodes a compressed body on the first read, so invalid
+// payloads fail within the handler instead of the middleware.
+type decodingBody struct {
+	body     io.ReadCloser
+	encoding string
+	decoder  io.ReadCloser
+}
+
+func (body *decodingBody) Read(data []byte) (int, error) {
+	if body.decoder == nil {
+		decoder, err := newBodyDecoder(body.encoding, body.body)
+		if err != nil {
+			return 0, err
+		}
+
+		body.decoder = decoder
+	}
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return body.decoder.Read(data)
+}
+
+func (body *decodingBody) Close() error {
+	var err error
+
+	if body.decoder != nil {
+		err = body.decoder.Close()
+	}
+
+	return errors.Join(err, body.body.Close())
+}
+
+func newBodyDecoder(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		decoder, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode body: %w", err)
+		}
+
+		return decoder, nil
+	case "deflate":
+		return flate.NewReader(body), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+// requestBodyRecorder tracks what a handler reads from the request body.
+type requestBodyRecorder struct {
+	contentLength int64
+	wire          *countingBody
+	// decoded is nil unless the middleware decodes the body.
+	decoded *countingBody
+}
+
+// recordRequestBody replaces the body of req with one that tracks reads. It
+// also decodes gzip and deflate bodies if decode is true, removing the
+// Content-Encoding header so handlers don't decode them twice.
+func recordRequestBody(req *http.Request, decode bool) *requestBodyRecorder {
+	//nolint:exhaustruct // nil bodies are not wrapped
+	recorder := &requestBodyRecorder{contentLength: req.ContentLength}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorder
+	}
+
+	//nolint:exhaustruct // counters start at zero
+	recorder.wire = &countingBody{ReadCloser: req.Body}
+	req.Body = recorder.wire
+
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if !decode || encoding == "" || encoding == "identity" || strings.Contains(encoding, ",") {
+		return recorder
+	}
+
+	//nolint:exhaustruct // counters start at zero
+	recorder.decoded = &countingBody{ReadCloser: &decodingBody{
+		body:     recorder.wire,
+		encoding: encoding,
+		decoder:  nil,
+	}}
+	req.Body = recorder.decoded
+	req.ContentLength = -1
+	req.Header = req.Header.Clone()
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+
+	return recorder
+}
+
+// size returns the bytes read from the wire, which are compressed if the
+// request has a Content-Encoding.
+func (recorder *requestBodyRecorder) size() int64 {
+	if recorder.wire == nil {
+		return 0
+	}
+
+	return recorder.wire.size
+}
+
+// attributes returns the body attributes that are only known after the handler
+// returns.
+func (recorder *requestBodyRecorder) attributes() []attribute.KeyValue {
+	mode := httpSemconv.get()
+	size := recorder.size()
+	attrs := make([]attribute.KeyValue, 0, 5)
+
+	if mode.emitsNew() {
+		attrs = append(attrs, attribute.Int64("http.request.body.size", size))
+	}
+
+	if mode.emitsOld() {
+		attrs = append(attrs, attribute.Int64("http.request_content_length", size))
+	}
+
+	if recorder.wire == nil {
+		return attrs
+	}
+
+	outer := recorder.wire
+	if recorder.decoded != nil {
+		outer = recorder.decoded
+		attrs = append(attrs, attribute.Int64(requestBodyDecodedSizeKey, recorder.decoded.size))
+	}
+
+	complete := outer.eof || (recorder.decoded == nil && recorder.contentLength >= 0 && size >= recorder.contentLength)
+
+	return append(attrs,
+		attribute.Float64(requestBodyReadDurationKey, outer.duration.Seconds()),
+		attribute.Bool(requestBodyEarlyCloseKey, !complete),
+	)
+}
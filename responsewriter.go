@@ -1,20 +1,40 @@
 package gotell
 
 import (
+	"bufio"
+	"io"
+	"net"
 	"net/http"
+	"slices"
+	"time"
 )
 
 var (
 	_ http.ResponseWriter = (*responseWriter)(nil)
 	_ ResponseWriter      = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+	_ io.ReaderFrom       = (*responseWriter)(nil)
+	_ http.Hijacker       = (*hijackerResponseWriter)(nil)
+	_ http.Pusher         = (*pusherResponseWriter)(nil)
+	_ http.Hijacker       = (*hijackerPusherResponseWriter)(nil)
+	_ http.Pusher         = (*hijackerPusherResponseWriter)(nil)
 )
 
 // ResponseWriter extends a http.ResponseWriter to record the status code and
 // content length.
+//
+// It implements http.Flusher and io.ReaderFrom, falling back to no-op flushes
+// and plain copies respectively if the original writer doesn't. It implements
+// http.Hijacker and http.Pusher only if the original writer does. Use
+// http.ResponseController to reach any other method, such as the deadlines.
 type ResponseWriter interface {
 	http.ResponseWriter
+	http.Flusher
+	io.ReaderFrom
 	ResponseStatusReporter
 	ResponseContentLengthReporter
+	ResponseInformationalReporter
+	ResponseTimingReporter
 
 	// Unwrap returns the original proxied target.
 	Unwrap() http.ResponseWriter
@@ -34,21 +54,67 @@ type ResponseContentLengthReporter interface {
 	ContentLength() int
 }
 
+// ResponseInformationalReporter is implemented by http.ResponseWriter values
+// that allow retrieving the 1xx informational responses sent to the client,
+// such as 103 Early Hints.
+type ResponseInformationalReporter interface {
+	// InformationalStatuses returns the 1xx status codes sent before the final
+	// one, in order.
+	InformationalStatuses() []int
+}
+
+// ResponseTimingReporter is implemented by http.ResponseWriter values that
+// allow retrieving when the response started.
+type ResponseTimingReporter interface {
+	// TimeToFirstByte returns the time between the writer creation and the
+	// final response header, or zero if it's not written yet.
+	TimeToFirstByte() time.Duration
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 
 	headerWritten bool
 	statusCode    int
 	contentLength int
+	informational []int
+	created       time.Time
+	firstByte     time.Duration
+}
+
+type hijackerResponseWriter struct {
+	*responseWriter
+}
+
+type pusherResponseWriter struct {
+	*responseWriter
+}
+
+type hijackerPusherResponseWriter struct {
+	*responseWriter
 }
 
 // NewResponseWriter wraps a http.ResponseWriter to record the status code and
-// content length.
+// content length. The result implements the same optional interfaces as w.
 //
 //nolint:ireturn // the underlying struct is private for safety purposes
 func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
 	//nolint:exhaustruct // primitive zero values are safe
-	return &responseWriter{ResponseWriter: w}
+	base := &responseWriter{ResponseWriter: w, created: time.Now()}
+
+	_, isHijacker := w.(http.Hijacker)
+	_, isPusher := w.(http.Pusher)
+
+	switch {
+	case isHijacker && isPusher:
+		return &hijackerPusherResponseWriter{base}
+	case isHijacker:
+		return &hijackerResponseWriter{base}
+	case isPusher:
+		return &pusherResponseWriter{base}
+	default:
+		return base
+	}
 }
 
 // Status returns the HTTP status of the request.
@@ -61,6 +127,17 @@ func (w *responseWriter) ContentLength() int {
 	return w.contentLength
 }
 
+// InformationalStatuses returns the 1xx status codes sent before the final one.
+func (w *responseWriter) InformationalStatuses() []int {
+	return slices.Clone(w.informational)
+}
+
+// TimeToFirstByte returns the time between the writer creation and the final
+// response header.
+func (w *responseWriter) TimeToFirstByte() time.Duration {
+	return w.firstByte
+}
+
 // Unwrap returns the original proxied target.
 func (w *responseWriter) Unwrap() http.ResponseWriter {
 	return w.ResponseWriter
@@ -68,14 +145,24 @@ func (w *responseWriter) Unwrap() http.ResponseWriter {
 
 // WriteHeader stores the status code before calling the original method.
 //
-// It does nothing if the headers are written already.
+// Informational 1xx codes, except 101 Switching Protocols, pass through without
+// counting as the final status. It does nothing if the headers are written
+// already.
 func (w *responseWriter) WriteHeader(code int) {
 	if w.headerWritten {
 		return
 	}
 
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.informational = append(w.informational, code)
+		w.ResponseWriter.WriteHeader(code)
+
+		return
+	}
+
 	w.statusCode = code
 	w.headerWritten = true
+	w.firstByte = time.Since(w.created)
 	w.ResponseWriter.WriteHeader(code)
 }
 
@@ -93,3 +180,68 @@ func (w *responseWriter) Write(data []byte) (int, error) {
 	//nolint:wrapcheck // passthrough, no need to wrap
 	return n, err
 }
+
+// Flush sends any buffered data to the client, if the original writer supports
+// it.
+func (w *responseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck // http.Flusher has no way to report unsupported writers
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// ReadFrom copies data from src, using the original method if available to
+// benefit from optimizations such as sendfile.
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.WriteHeader(http.StatusOK)
+
+	var (
+		n   int64
+		err error
+	)
+
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, src)
+	}
+
+	w.contentLength += int(n)
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return n, err
+}
+
+// Hijack lets the caller take over the connection.
+//
+//nolint:wrapcheck // passthrough, no need to wrap
+func (w *hijackerResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Push initiates an HTTP/2 server push.
+//
+//nolint:wrapcheck,forcetypeassert // passthrough, checked on creation
+func (w *pusherResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// Hijack lets the caller take over the connection.
+//
+//nolint:wrapcheck // passthrough, no need to wrap
+func (w *hijackerPusherResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Push initiates an HTTP/2 server push.
+//
+//nolint:wrapcheck,forcetypeassert // passthrough, checked on creation
+func (w *hijackerPusherResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// writerOnly hides any optional interface of the writer, such as io.ReaderFrom,
+// to prevent io.Copy from calling it back.
+type writerOnly struct {
+	io.Writer
+}
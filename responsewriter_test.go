@@ -0,0 +1,75 @@
+package gotell_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestNewResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	recorded := gotell.NewResponseWriter(httptest.NewRecorder())
+
+	_, isHijacker := recorded.(http.Hijacker)
+	assert.False(t, isHijacker, "recorder can't hijack")
+
+	_, isPusher := recorded.(http.Pusher)
+	assert.False(t, isPusher, "recorder can't push")
+
+	type result struct {
+		hijacker      bool
+		informational []int
+		status        int
+		length        int
+		firstByte     bool
+	}
+
+	results := make(chan result, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resWriter := gotell.NewResponseWriter(w)
+		_, hijacker := resWriter.(http.Hijacker)
+
+		resWriter.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		resWriter.WriteHeader(http.StatusEarlyHints)
+		resWriter.WriteHeader(http.StatusAccepted)
+		resWriter.Flush()
+
+		_, err := resWriter.ReadFrom(strings.NewReader("hello"))
+		assert.NoError(t, err)
+
+		results <- result{
+			hijacker:      hijacker,
+			informational: resWriter.InformationalStatuses(),
+			status:        resWriter.Status(),
+			length:        resWriter.ContentLength(),
+			firstByte:     resWriter.TimeToFirstByte() > 0,
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	res, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, result{
+		hijacker:      true,
+		informational: []int{http.StatusEarlyHints},
+		status:        http.StatusAccepted,
+		length:        len("hello"),
+		firstByte:     true,
+	}, <-results)
+}
@@ -1,6 +1,11 @@
 package gotell
 
-import "net/http"
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
 
 // RoundTripperFn is a functor that implements http.RoundTripper
 type RoundTripperFn func(req *http.Request) (*http.Response, error)
@@ -11,3 +16,80 @@ type RoundTripperFn func(req *http.Request) (*http.Response, error)
 func (fn RoundTripperFn) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+// wrappedRoundTripper is a RoundTripperFn that forwards CloseIdleConnections
+// to the round tripper it wraps, so http.Client.CloseIdleConnections still
+// reaches the transport.
+type wrappedRoundTripper struct {
+	RoundTripperFn
+
+	next http.RoundTripper
+}
+
+//nolint:ireturn // same as the upstream round trippers
+func wrapRoundTripper(next http.RoundTripper, fn RoundTripperFn) http.RoundTripper {
+	return &wrappedRoundTripper{
+		RoundTripperFn: fn,
+		next:           next,
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped round
+// tripper, if it supports it.
+func (rt *wrappedRoundTripper) CloseIdleConnections() {
+	if closer, ok := rt.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// onBodyDone calls fn once the caller is done with the response body, which is
+// on EOF, on a read error or on close, whichever comes first. Bodies without
+// content, or that upgrade the connection, call it at once.
+func onBodyDone(res *http.Response, fn func(err error)) {
+	if res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+		fn(nil)
+
+		return
+	}
+
+	res.Body = &notifyingBody{
+		ReadCloser: res.Body,
+		done:       fn,
+		once:       sync.Once{},
+	}
+}
+
+type notifyingBody struct {
+	io.ReadCloser
+
+	done func(err error)
+	once sync.Once
+}
+
+func (body *notifyingBody) Read(data []byte) (int, error) {
+	n, err := body.ReadCloser.Read(data)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		body.notify(nil)
+	default:
+		body.notify(err)
+	}
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return n, err
+}
+
+func (body *notifyingBody) Close() error {
+	defer body.notify(nil)
+
+	//nolint:wrapcheck // passthrough, no need to wrap
+	return body.ReadCloser.Close()
+}
+
+func (body *notifyingBody) notify(err error) {
+	body.once.Do(func() {
+		body.done(err)
+	})
+}
@@ -0,0 +1,183 @@
+package gotell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExitSuccess is the exit code for a run that returned no error.
+	ExitSuccess = 0
+	// ExitFailure is the exit code for a run that returned an error without a
+	// specific exit code.
+	ExitFailure = 1
+
+	signalExitBase = 128
+)
+
+// ErrPanic happens when an instrumented function panics. The panic value is
+// part of the error message.
+var ErrPanic = errors.New("panic")
+
+// RunFn is the body of an application executed by Run.
+type RunFn func(ctx context.Context) error
+
+// ExitCoder is implemented by errors that carry a specific process exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// SignalError reports that a run stopped due to an operating system signal.
+type SignalError struct {
+	Signal os.Signal
+}
+
+// Error returns the signal description.
+func (err *SignalError) Error() string {
+	return "received signal: " + err.Signal.String()
+}
+
+// ExitCode follows the shell convention of 128 plus the signal number.
+func (err *SignalError) ExitCode() int {
+	if sig, ok := err.Signal.(syscall.Signal); ok {
+		return signalExitBase + int(sig)
+	}
+
+	return ExitFailure
+}
+
+// Run initializes telemetry, executes fn within a root span and shuts down the
+// providers before returning an exit code suitable for os.Exit.
+//
+// It cancels the context given to fn on SIGINT or SIGTERM; the cause is then a
+// SignalError. Shutdown runs on a context detached from the signal with the
+// deadline set by WithShutdownTimeout, so telemetry flushes even after a
+// cancellation.
+//
+// A panic from fn is recorded as an exception on the root span, then Run shuts
+// down the providers and re-panics with the original value.
+//
+// Errors are logged and mapped to an exit code by ExitCode. Errors from fn go
+// through Slog, so they reach both the default slog logger and the telemetry
+// pipeline before it shuts down. Setup and shutdown errors go through the
+// default slog logger only.
+func Run(ctx context.Context, res *resource.Resource, fn RunFn, options ...Option) int {
+	opts, err := NewOptions(ctx, options...)
+	if err != nil {
+		err = fmt.Errorf("failed to build options: %w", err)
+		logRunError(ctx, slog.Default(), err)
+
+		return ExitCode(err)
+	}
+
+	ctx, stop := notifySignals(ctx)
+	defer stop()
+
+	err = initialize(res, opts)
+	if err != nil {
+		err = fmt.Errorf("failed to initialize telemetry: %w", err)
+		logRunError(ctx, slog.Default(), err)
+
+		return ExitCode(err)
+	}
+
+	shutdown := func() error {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opts.ShutdownTimeout)
+		defer cancel()
+
+		return Shutdown(ctx)
+	}
+
+	ctx, span := tracerStart(ctx, Tracer(ctx), "")
+
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+
+		recordPanic(span, value)
+		span.End()
+
+		_ = shutdown()
+
+		panic(value)
+	}()
+
+	err = span.Assert(fn(ctx))
+	if cause := context.Cause(ctx); err != nil && cause != nil && errors.Is(err, ctx.Err()) {
+		err = cause
+	}
+
+	// logged before the span ends, so the record belongs to it
+	logRunError(ctx, Slog(), err)
+
+	span.End()
+
+	shutdownErr := shutdown()
+	logRunError(ctx, slog.Default(), shutdownErr)
+
+	return ExitCode(errors.Join(err, shutdownErr))
+}
+
+// ExitCode maps an error to a process exit code. A nil error means success.
+// Errors that implement ExitCoder, directly or wrapped, define their own code.
+// Any other error means failure.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitCoder ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+
+	return ExitFailure
+}
+
+// logRunError logs the error, if any.
+func logRunError(ctx context.Context, logger *slog.Logger, err error) {
+	if err != nil {
+		logger.ErrorContext(ctx, "run failed", slog.Any("error", err))
+	}
+}
+
+// notifySignals cancels the context with a SignalError once the process
+// receives either SIGINT or SIGTERM.
+func notifySignals(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			cancel(&SignalError{Signal: sig})
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(context.Canceled)
+	}
+}
+
+// recordPanic records a recovered value as an exception with a stack trace.
+func recordPanic(span trace.Span, value any) {
+	err := fmt.Errorf("%w: %v", ErrPanic, value)
+
+	span.RecordError(err, trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,232 @@
+package gotell_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/wwmoraes/gotell"
+)
+
+const runScenarioEnv = "GOTELL_RUN_SCENARIO"
+
+var errUsage = &exitCodeError{code: 3}
+
+type exitCodeError struct {
+	code int
+}
+
+func (err *exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", err.code)
+}
+
+func (err *exitCodeError) ExitCode() int {
+	return err.code
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, gotell.ExitSuccess},
+		{"plain", errSentinel, gotell.ExitFailure},
+		{"exit coder", errUsage, 3},
+		{"wrapped exit coder", fmt.Errorf("usage: %w", errUsage), 3},
+		{"joined exit coder", errors.Join(errSentinel, errUsage), 3},
+		{"signal", &gotell.SignalError{Signal: syscall.SIGTERM}, 128 + int(syscall.SIGTERM)},
+		{"interrupt", &gotell.SignalError{Signal: os.Interrupt}, 128 + int(syscall.SIGINT)},
+		{"wrapped signal", fmt.Errorf("stopped: %w", &gotell.SignalError{Signal: syscall.SIGINT}), 130},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, gotell.ExitCode(tt.err))
+		})
+	}
+}
+
+type customSignal struct{}
+
+func (customSignal) String() string { return "custom" }
+
+func (customSignal) Signal() {}
+
+func TestSignalError(t *testing.T) {
+	t.Parallel()
+
+	err := &gotell.SignalError{Signal: syscall.SIGTERM}
+
+	assert.Equal(t, "received signal: "+syscall.SIGTERM.String(), err.Error())
+	assert.Equal(t, 143, err.ExitCode())
+
+	// only syscall signals have a number
+	assert.Equal(t, gotell.ExitFailure, (&gotell.SignalError{Signal: customSignal{}}).ExitCode())
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario   string
+		wantCode   int
+		wantStderr []string
+	}{
+		{"success", gotell.ExitSuccess, []string{"exported spans: 1"}},
+		{"error", 3, []string{"run failed", "exit code 3", "exported spans: 1"}},
+		{"signal", 143, []string{"run failed", "received signal: terminated", "exported spans: 1"}},
+		{"panic", 2, []string{"exported spans: 1", "panic: boom"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			code, stderr := runScenario(t, tt.scenario)
+
+			assert.Equal(t, tt.wantCode, code, stderr)
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr, want)
+			}
+		})
+	}
+}
+
+// runScenario runs TestRunScenario in a subprocess, as Run sets the global
+// providers and may re-panic.
+func runScenario(t *testing.T, scenario string) (int, string) {
+	t.Helper()
+
+	//nolint:gosec // runs the test binary itself
+	cmd := exec.CommandContext(context.Background(), os.Args[0], "-test.run=^TestRunScenario$")
+	cmd.Env = append(os.Environ(), runScenarioEnv+"="+scenario)
+
+	var stderr bytes.Buffer
+
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stderr.String()
+	}
+
+	require.NoError(t, err)
+
+	return 0, stderr.String()
+}
+
+//nolint:paralleltest // runs only as a subprocess of TestRun
+func TestRunScenario(t *testing.T) {
+	scenario := os.Getenv(runScenarioEnv)
+	if scenario == "" {
+		t.Skip("subprocess of TestRun")
+	}
+
+	fns := map[string]gotell.RunFn{
+		"success": func(context.Context) error {
+			return nil
+		},
+		"error": func(context.Context) error {
+			return fmt.Errorf("usage: %w", errUsage)
+		},
+		"signal": func(ctx context.Context) error {
+			process, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+
+			if err := process.Signal(syscall.SIGTERM); err != nil {
+				return err
+			}
+
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+		"panic": func(context.Context) error {
+			panic("boom")
+		},
+	}
+
+	os.Exit(gotell.Run(
+		context.Background(),
+		resource.Empty(),
+		fns[scenario],
+		gotell.WithTracesExporter(&stderrSpanExporter{}),
+		gotell.WithLogsExporter(discardLogExporter{}),
+		gotell.WithMetricsExporter(discardMetricExporter{}),
+		gotell.WithShutdownTimeout(time.Second),
+	))
+}
+
+// stderrSpanExporter reports the amount of exported spans on the standard
+// error, so TestRun can tell whether Run flushed them.
+type stderrSpanExporter struct{}
+
+func (*stderrSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	_, err := fmt.Fprintf(os.Stderr, "exported spans: %d\n", len(spans))
+
+	return err
+}
+
+func (*stderrSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+type discardLogExporter struct{}
+
+func (discardLogExporter) Export(context.Context, []sdklog.Record) error {
+	return nil
+}
+
+func (discardLogExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (discardLogExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+type discardMetricExporter struct{}
+
+func (discardMetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (discardMetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (discardMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	return nil
+}
+
+func (discardMetricExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (discardMetricExporter) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package gotell
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	rtrace "runtime/trace"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+//nolint:gochecknoglobals // toggled once during initialization
+var runtimeTraceBridge atomic.Bool
+
+// WithRuntimeTrace bridges spans created by Start and StartNamed to the
+// runtime/trace package. Root spans open a runtime task and child spans open a
+// region within it, both named after the span.
+//
+// The bridge only records while execution tracing is enabled, e.g. through
+// runtime/trace.Start, go test -trace or a FlightRecorder. It costs a single
+// atomic load per span otherwise. See FlightRecorderHandler and
+// WithSlowRequestTrace to take snapshots of a FlightRecorder.
+//
+// Regions must end on the same goroutine that started them. Spans that end on
+// a different goroutine will produce unbalanced regions on the execution trace.
+func WithRuntimeTrace() Option {
+	return OptionFn(func(opts *Options) error {
+		opts.RuntimeTrace = true
+
+		return nil
+	})
+}
+
+// SetRuntimeTrace toggles the runtime/trace bridge at runtime.
+//
+// See WithRuntimeTrace for details.
+func SetRuntimeTrace(enabled bool) {
+	runtimeTraceBridge.Store(enabled)
+}
+
+// startRuntimeTrace opens a runtime/trace task for root spans or a region for
+// child ones. It returns a nil end function if the bridge is off or the
+// execution tracer isn't running.
+func startRuntimeTrace(
+	ctx context.Context,
+	parent trace.SpanContext,
+	spanName string,
+) (context.Context, func()) {
+	if !runtimeTraceBridge.Load() || !rtrace.IsEnabled() {
+		return ctx, nil
+	}
+
+	if !parent.IsValid() || parent.IsRemote() {
+		ctx, task := rtrace.NewTask(ctx, spanName)
+
+		return ctx, task.End
+	}
+
+	return ctx, rtrace.StartRegion(ctx, spanName).End
+}
+
+// FlightRecorder is an execution trace ring buffer, such as the
+// runtime/trace.FlightRecorder from Go 1.25 or the one from
+// golang.org/x/exp/trace. The caller starts and stops it.
+type FlightRecorder interface {
+	// WriteTo writes a snapshot of the recent execution trace to w.
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// TraceDumpFn receives an execution trace snapshot, e.g. to store it for
+// go tool trace.
+type TraceDumpFn func(ctx context.Context, snapshot []byte)
+
+// FlightRecorderHandler serves a snapshot of the recorder on demand, suited
+// for go tool trace. It responds with 503 Service Unavailable if the recorder
+// fails, such as when it isn't running.
+func FlightRecorderHandler(recorder FlightRecorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var snapshot bytes.Buffer
+
+		_, err := recorder.WriteTo(&snapshot)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="trace.out"`)
+
+		_, _ = snapshot.WriteTo(w)
+	})
+}
+
+// slowRequestTracer dumps a flight recorder snapshot after slow requests, one
+// at a time, as recorders don't support concurrent snapshots.
+type slowRequestTracer struct {
+	recorder  FlightRecorder
+	threshold time.Duration
+	dump      TraceDumpFn
+	running   atomic.Bool
+}
+
+// observe dumps a snapshot in the background if the request took longer than
+// the threshold. It skips requests that end while a snapshot is running.
+func (tracer *slowRequestTracer) observe(ctx context.Context, elapsed time.Duration) {
+	if tracer == nil || elapsed < tracer.threshold || !tracer.running.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer tracer.running.Store(false)
+
+		var snapshot bytes.Buffer
+
+		if _, err := tracer.recorder.WriteTo(&snapshot); err != nil {
+			return
+		}
+
+		tracer.dump(ctx, snapshot.Bytes())
+	}()
+}
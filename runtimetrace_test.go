@@ -0,0 +1,153 @@
+package gotell_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime/trace"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var errNotRecording = errors.New("flight recorder not running")
+
+// fakeFlightRecorder writes a fixed snapshot, or fails.
+type fakeFlightRecorder struct {
+	snapshot []byte
+	err      error
+	writes   atomic.Int32
+}
+
+func (recorder *fakeFlightRecorder) WriteTo(w io.Writer) (int64, error) {
+	recorder.writes.Add(1)
+
+	if recorder.err != nil {
+		return 0, recorder.err
+	}
+
+	n, err := w.Write(recorder.snapshot)
+
+	return int64(n), err
+}
+
+func TestSpanEndRuntimeTraceOnce(t *testing.T) {
+	t.Parallel()
+
+	var ends int
+
+	span := gotell.NewRuntimeTraceSpan(noop.Span{}, func() { ends++ })
+
+	span.End()
+	span.End()
+
+	assert.Equal(t, 1, ends)
+}
+
+//nolint:paralleltest // toggles the process-wide runtime trace bridge
+func TestRuntimeTraceBridge(t *testing.T) {
+	var output bytes.Buffer
+
+	if err := trace.Start(&output); err != nil {
+		t.Skipf("execution tracer busy: %v", err)
+	}
+
+	gotell.SetRuntimeTrace(true)
+	t.Cleanup(func() { gotell.SetRuntimeTrace(false) })
+
+	ctx, _ := newRecordedContext(t)
+
+	ctx, root := gotell.StartNamed(ctx, "root")
+	_, child := gotell.StartNamed(ctx, "child")
+
+	// ending twice must not unbalance the regions and tasks
+	child.End()
+	child.End()
+	root.End()
+	root.End()
+
+	trace.Stop()
+
+	assert.NotZero(t, output.Len())
+}
+
+func TestFlightRecorderHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		recorder *fakeFlightRecorder
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "snapshot",
+			recorder: &fakeFlightRecorder{snapshot: []byte("trace"), err: nil, writes: atomic.Int32{}},
+			wantCode: http.StatusOK,
+			wantBody: "trace",
+		},
+		{
+			name:     "stopped",
+			recorder: &fakeFlightRecorder{snapshot: nil, err: errNotRecording, writes: atomic.Int32{}},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: errNotRecording.Error() + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := httptest.NewRecorder()
+
+			gotell.FlightRecorderHandler(tt.recorder).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, res.Code)
+			assert.Equal(t, tt.wantBody, res.Body.String())
+		})
+	}
+}
+
+func TestWithSlowRequestTrace(t *testing.T) {
+	t.Parallel()
+
+	recorder := &fakeFlightRecorder{snapshot: []byte("trace"), err: nil, writes: atomic.Int32{}}
+	dumps := make(chan []byte, 1)
+
+	handler := gotell.NewInstrumentationMiddleware(
+		gotell.WithSlowRequestTrace(recorder, 10*time.Millisecond, func(_ context.Context, snapshot []byte) {
+			dumps <- snapshot
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	ctx, _ := newRecordedContext(t)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, http.MethodGet, "/fast", nil))
+	assert.Zero(t, recorder.writes.Load())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, http.MethodGet, "/slow", nil))
+
+	select {
+	case snapshot := <-dumps:
+		require.Equal(t, []byte("trace"), snapshot)
+	case <-time.After(time.Second):
+		require.Fail(t, "no snapshot of the slow request")
+	}
+
+	assert.Equal(t, int32(1), recorder.writes.Load())
+}
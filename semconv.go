@@ -0,0 +1,132 @@
+package gotell
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SemconvStability selects which version of a semantic convention domain to
+// emit during the migration to its stable version. Unknown values behave as
+// SemconvFromEnv.
+//
+// See https://opentelemetry.io/docs/specs/semconv/non-normative/code-migration/
+type SemconvStability uint32
+
+const (
+	// SemconvFromEnv resolves the mode from OTEL_SEMCONV_STABILITY_OPT_IN: the
+	// domain name, such as code, selects the new conventions; the domain name
+	// followed by /dup, such as code/dup, selects both. Anything else selects
+	// the domain default: the old conventions for code, and the new ones for
+	// http, which gotell always emitted.
+	SemconvFromEnv SemconvStability = iota
+	// SemconvOld emits only the deprecated conventions.
+	SemconvOld
+	// SemconvNew emits only the current conventions.
+	SemconvNew
+	// SemconvDuplicate emits both the deprecated and the current conventions.
+	SemconvDuplicate
+
+	semconvModes = 3
+)
+
+const semconvStabilityOptInEnv = "OTEL_SEMCONV_STABILITY_OPT_IN"
+
+//nolint:gochecknoglobals // process-wide migration switches
+var (
+	codeSemconv = newSemconvSwitch("code", SemconvOld)
+	httpSemconv = newSemconvSwitch("http", SemconvNew)
+)
+
+// WithCodeSemconv selects the code attributes to emit on spans and logs.
+func WithCodeSemconv(mode SemconvStability) Option {
+	return OptionFn(func(opts *Options) error {
+		opts.CodeSemconv = mode
+
+		return nil
+	})
+}
+
+// SetCodeSemconv selects the code attributes to emit at runtime.
+//
+// See WithCodeSemconv for details.
+func SetCodeSemconv(mode SemconvStability) {
+	codeSemconv.set(mode)
+}
+
+// WithHTTPSemconv selects the HTTP attributes to emit on spans and metrics.
+func WithHTTPSemconv(mode SemconvStability) Option {
+	return OptionFn(func(opts *Options) error {
+		opts.HTTPSemconv = mode
+
+		return nil
+	})
+}
+
+// SetHTTPSemconv selects the HTTP attributes to emit at runtime.
+//
+// See WithHTTPSemconv for details.
+func SetHTTPSemconv(mode SemconvStability) {
+	httpSemconv.set(mode)
+}
+
+// emitsOld reports whether the mode includes the deprecated conventions.
+func (mode SemconvStability) emitsOld() bool {
+	return mode != SemconvNew
+}
+
+// emitsNew reports whether the mode includes the current conventions.
+func (mode SemconvStability) emitsNew() bool {
+	return mode == SemconvNew || mode == SemconvDuplicate
+}
+
+// semconvSwitch holds the mode of a single domain. It resolves the environment
+// variable once, on the first use of SemconvFromEnv.
+type semconvSwitch struct {
+	mode    atomic.Uint32
+	fromEnv func() SemconvStability
+}
+
+func newSemconvSwitch(domain string, fallback SemconvStability) *semconvSwitch {
+	return &semconvSwitch{
+		mode: atomic.Uint32{},
+		fromEnv: sync.OnceValue(func() SemconvStability {
+			return semconvStabilityFromEnv(domain, fallback)
+		}),
+	}
+}
+
+// set stores the mode. Unknown modes fall back to SemconvFromEnv.
+func (sw *semconvSwitch) set(mode SemconvStability) {
+	if mode > SemconvDuplicate {
+		mode = SemconvFromEnv
+	}
+
+	sw.mode.Store(uint32(mode))
+}
+
+// get returns the resolved mode, which is never SemconvFromEnv.
+func (sw *semconvSwitch) get() SemconvStability {
+	mode := SemconvStability(sw.mode.Load())
+	if mode == SemconvFromEnv {
+		return sw.fromEnv()
+	}
+
+	return mode
+}
+
+func semconvStabilityFromEnv(domain string, fallback SemconvStability) SemconvStability {
+	mode := fallback
+
+	for _, value := range strings.Split(os.Getenv(semconvStabilityOptInEnv), ",") {
+		switch strings.TrimSpace(value) {
+		case domain + "/dup":
+			return SemconvDuplicate
+		case domain:
+			mode = SemconvNew
+		}
+	}
+
+	return mode
+}
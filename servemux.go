@@ -0,0 +1,65 @@
+package gotell
+
+import (
+	"net/http"
+	"slices"
+)
+
+var _ http.Handler = (*ServeMux)(nil)
+
+// ServeMux is an http.ServeMux that instruments each route on its own, so
+// routes can customize the instrumentation.
+//
+// Requests that match no route are instrumented with the mux options.
+type ServeMux struct {
+	mux      *http.ServeMux
+	options  []InstrumentationOption
+	notFound http.Handler
+}
+
+// NewServeMux creates an instrumented ServeMux. The options apply to all
+// routes, before any route-specific ones.
+func NewServeMux(options ...InstrumentationOption) *ServeMux {
+	mux := http.NewServeMux()
+
+	return &ServeMux{
+		mux:      mux,
+		options:  options,
+		notFound: NewInstrumentationMiddleware(options...)(mux),
+	}
+}
+
+// Handle registers an instrumented handler for the pattern.
+//
+// See http.ServeMux.Handle for the pattern syntax.
+func (mux *ServeMux) Handle(pattern string, handler http.Handler, options ...InstrumentationOption) {
+	mux.mux.Handle(pattern, NewInstrumentationMiddleware(slices.Concat(mux.options, options)...)(handler))
+}
+
+// HandleFunc registers an instrumented handler function for the pattern.
+func (mux *ServeMux) HandleFunc(
+	pattern string,
+	handler func(http.ResponseWriter, *http.Request),
+	options ...InstrumentationOption,
+) {
+	mux.Handle(pattern, http.HandlerFunc(handler), options...)
+}
+
+// Handler returns the handler to use for the request and its pattern.
+//
+// See http.ServeMux.Handler.
+func (mux *ServeMux) Handler(req *http.Request) (http.Handler, string) {
+	return mux.mux.Handler(req)
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern most closely
+// matches it.
+func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := mux.mux.Handler(req); pattern == "" {
+		mux.notFound.ServeHTTP(w, req)
+
+		return
+	}
+
+	mux.mux.ServeHTTP(w, req)
+}
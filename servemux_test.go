@@ -0,0 +1,87 @@
+package gotell_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestServeMux(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pattern  string
+		target   string
+		options  []gotell.InstrumentationOption
+		wantName string
+		wantCode int
+	}{
+		{
+			name:     "pattern",
+			pattern:  "GET /users/{id}",
+			target:   "/users/42",
+			options:  nil,
+			wantName: "GET /users/{id}",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "host pattern",
+			pattern:  "example.com/users/{id}",
+			target:   "http://example.com/users/42",
+			options:  nil,
+			wantName: "GET /users/{id}",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "route option",
+			pattern:  "/files/",
+			target:   "/files/a/b/c",
+			options:  []gotell.InstrumentationOption{gotell.WithRoute("/files/{path...}")},
+			wantName: "GET /files/{path...}",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "not found",
+			pattern:  "GET /users/{id}",
+			target:   "/groups/42",
+			options:  nil,
+			wantName: "GET",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, recorder := newRecordedContext(t)
+
+			mux := gotell.NewServeMux()
+			mux.HandleFunc(tt.pattern, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}, tt.options...)
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, tt.target, nil)
+			res := httptest.NewRecorder()
+
+			mux.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.wantCode, res.Code)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantName, spans[0].Name())
+
+			if tt.wantCode == http.StatusOK {
+				assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", tt.wantName[len("GET "):]))
+			}
+		})
+	}
+}
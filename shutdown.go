@@ -3,10 +3,7 @@ package gotell
 import (
 	"context"
 	"errors"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/log/global"
-	"golang.org/x/sync/errgroup"
+	"slices"
 )
 
 // ErrShutdownFailed happens when a provider fails to shutdown
@@ -18,43 +15,24 @@ type Shutdowner interface {
 	Shutdown(ctx context.Context) error
 }
 
-// Shutdown shuts down the default logger, metric and tracer providers. Each
-// will run on a separate goroutine. It'll return the first error if any happens
-// and cancel the other routines.
+// Shutdown stops components added by RegisterShutdowner in reverse registration
+// order, then shuts down the default tracer, logger and meter providers, in
+// this order. Traces go first so spans that end during shutdown can still emit
+// logs and metrics, and metrics go last to account for everything else.
+//
+// Each target runs even if a previous one fails. The result joins all errors,
+// each tagged through a ProviderError or ComponentError. Use WithSignalTimeout to
+// bound individual providers.
 //
 // It works on any provider that implements the Shutdowner interface. The OTEL
 // standard providers do, for instance. It'll ignore providers that don't.
-func Shutdown(ctx context.Context) error {
-	group, ctx := errgroup.WithContext(ctx)
-
-	group.Go(func() error {
-		err := tryShutdown(ctx, global.GetLoggerProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	group.Go(func() error {
-		err := tryShutdown(ctx, otel.GetMeterProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	group.Go(func() error {
-		err := tryShutdown(ctx, otel.GetTracerProvider())
-		if !errors.Is(err, errors.ErrUnsupported) {
-			return err
-		}
-
-		return nil
-	})
-
-	err := group.Wait()
+func Shutdown(ctx context.Context, options ...LifecycleOption) error {
+	err := runLifecycle(
+		ctx,
+		newLifecycleOptions(options...),
+		withGlobalProviders(slices.Concat(shutdownRegistry.snapshot(true), providerEntries(true))),
+		tryShutdown,
+	)
 	if err != nil {
 		return errors.Join(ErrShutdownFailed, err)
 	}
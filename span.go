@@ -2,6 +2,7 @@ package gotell
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -14,8 +15,13 @@ type Span interface {
 	// Assert changes the span status based on the error. It returns the
 	// unmodified error.
 	//
-	// A non-nil error will record it and set the span status to codes.Error.
-	// Conversely, a nil error will set the span status to Ok.
+	// A non-nil error sets the span status to codes.Error and the error.type
+	// attribute as classified by ErrorType. It records each error of an
+	// errors.Join or %w chain as a separate exception event, with stack traces
+	// if enabled by WithErrorStackTraces.
+	//
+	// A nil error sets the span status to Ok. Expected errors, as reported by
+	// IsExpectedError, leave the status unchanged.
 	Assert(err error) error
 
 	// Errorf is a drop-in replacement for fmt.Errorf. It creates a new error
@@ -25,15 +31,34 @@ type Span interface {
 
 type span struct {
 	trace.Span
+
+	// endRuntimeTrace closes the runtime/trace task or region of the span, if
+	// any.
+	endRuntimeTrace func()
+	ended           atomic.Bool
+}
+
+// End completes the span, then closes any runtime/trace region or task opened
+// for it. Further calls don't close them again.
+func (s *span) End(options ...trace.SpanEndOption) {
+	s.Span.End(options...)
+
+	if s.endRuntimeTrace != nil && s.ended.CompareAndSwap(false, true) {
+		s.endRuntimeTrace()
+	}
 }
 
 //nolint:revive // unexported struct, no docs needed
 func (s *span) Assert(err error) error {
-	if err == nil {
+	switch {
+	case err == nil:
 		s.SetStatus(codes.Ok, "")
-	} else {
+	case IsExpectedError(err):
+		// not a failure, so the status stays as-is
+	default:
 		s.SetStatus(codes.Error, err.Error())
-		s.RecordError(err)
+		s.SetAttributes(errorTypeKey.String(ErrorType(err)))
+		recordErrors(s, err)
 	}
 
 	return err
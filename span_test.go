@@ -0,0 +1,119 @@
+package gotell_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var errSentinel = errors.New("sentinel")
+
+//nolint:gochecknoinits // registration is process-wide
+func init() {
+	gotell.RegisterErrorType(errSentinel, "sentinel")
+}
+
+func TestSpanAssert(t *testing.T) {
+	t.Parallel()
+
+	pathErr := &fs.PathError{Op: "open", Path: "/foo", Err: fs.ErrNotExist}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantType   string
+		wantEvents int
+	}{
+		{
+			name:       "nil",
+			err:        nil,
+			wantCode:   codes.Ok,
+			wantType:   "",
+			wantEvents: 0,
+		},
+		{
+			name:       "canceled",
+			err:        fmt.Errorf("stopped: %w", context.Canceled),
+			wantCode:   codes.Unset,
+			wantType:   "",
+			wantEvents: 0,
+		},
+		{
+			name:       "concrete type",
+			err:        fmt.Errorf("failed: %w", pathErr),
+			wantCode:   codes.Error,
+			wantType:   "*fs.PathError",
+			wantEvents: 3,
+		},
+		{
+			name:       "sentinel",
+			err:        fmt.Errorf("failed: %w", errSentinel),
+			wantCode:   codes.Error,
+			wantType:   "sentinel",
+			wantEvents: 2,
+		},
+		{
+			name:       "joined canceled",
+			err:        errors.Join(context.Canceled, fmt.Errorf("stopped: %w", context.Canceled)),
+			wantCode:   codes.Unset,
+			wantType:   "",
+			wantEvents: 0,
+		},
+		{
+			name:       "joined with canceled",
+			err:        errors.Join(errors.New("disk full"), context.Canceled),
+			wantCode:   codes.Error,
+			wantType:   gotell.ErrorTypeOther,
+			wantEvents: 2,
+		},
+		{
+			name:       "joined",
+			err:        errors.Join(errors.New("foo"), errors.New("bar")),
+			wantCode:   codes.Error,
+			wantType:   gotell.ErrorTypeOther,
+			wantEvents: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(t.Name())
+
+			ctx, _ := tracer.Start(context.Background(), t.Name())
+
+			span := gotell.SpanFromContext(ctx)
+			assert.Equal(t, tt.err, span.Assert(tt.err))
+			span.End()
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantCode, spans[0].Status().Code)
+			assert.Len(t, spans[0].Events(), tt.wantEvents)
+
+			var gotType string
+
+			for _, attr := range spans[0].Attributes() {
+				if attr.Key == attribute.Key("error.type") {
+					gotType = attr.Value.AsString()
+				}
+			}
+
+			assert.Equal(t, tt.wantType, gotType)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gotell
+
+import (
+	"context"
+	"iter"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace runs fn within a new span named after it, and asserts the span with
+// the returned error.
+//
+// The span has the code attributes of fn instead of the caller. A panic is
+// recorded as an exception with a stack trace, then re-panics with the
+// original value after the span ends.
+func Trace(ctx context.Context, fn func(ctx context.Context) error, opts ...trace.SpanStartOption) error {
+	ctx, span := tracerStartInfo(ctx, Tracer(ctx), "", GetFunctionValueInfo(fn), opts...)
+	defer endRecoveringSpan(span)
+
+	return span.Assert(fn(ctx))
+}
+
+// TraceValue is the same as Trace for functions that also return a value.
+func TraceValue[T any](
+	ctx context.Context,
+	fn func(ctx context.Context) (T, error),
+	opts ...trace.SpanStartOption,
+) (T, error) {
+	ctx, span := tracerStartInfo(ctx, Tracer(ctx), "", GetFunctionValueInfo(fn), opts...)
+	defer endRecoveringSpan(span)
+
+	value, err := fn(ctx)
+
+	return value, span.Assert(err)
+}
+
+// TraceSeq wraps a sequence so its iteration happens within a span named after
+// the sequence function. The span starts on the first pull and ends when the
+// iteration does, be it exhausted or stopped early by the consumer.
+//
+// The span has the code attributes of the sequence function. A panic within
+// the sequence is recorded the same way as Trace does.
+func TraceSeq[T any](ctx context.Context, seq iter.Seq[T], opts ...trace.SpanStartOption) iter.Seq[T] {
+	info := GetFunctionValueInfo(seq)
+
+	return func(yield func(T) bool) {
+		_, span := tracerStartInfo(ctx, Tracer(ctx), "", info, opts...)
+		defer endRecoveringSpan(span)
+
+		seq(yield)
+
+		span.Assert(nil)
+	}
+}
+
+// endRecoveringSpan ends the span. If the goroutine is panicking, it records
+// the panic on the span first, then re-panics with the same value.
+//
+// It must be deferred directly for recover to work.
+func endRecoveringSpan(span Span) {
+	value := recover()
+	if value == nil {
+		span.End()
+
+		return
+	}
+
+	recordPanic(span, value)
+	span.End()
+
+	panic(value)
+}
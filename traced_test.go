@@ -0,0 +1,116 @@
+package gotell_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/wwmoraes/gotell"
+)
+
+var errTraced = errors.New("traced")
+
+func tracedFunction(context.Context) error {
+	return errTraced
+}
+
+func tracedValue(context.Context) (int, error) {
+	return 42, nil
+}
+
+func tracedPanic(context.Context) error {
+	panic("boom")
+}
+
+func tracedSeq(yield func(int) bool) {
+	for i := range 10 {
+		if !yield(i) {
+			return
+		}
+	}
+}
+
+func newRecordedContext(t *testing.T) (context.Context, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(t.Name())
+
+	ctx, span := tracer.Start(context.Background(), t.Name())
+	t.Cleanup(func() { span.End() })
+
+	return ctx, recorder
+}
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	require.ErrorIs(t, gotell.Trace(ctx, tracedFunction), errTraced)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "tracedFunction", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestTraceValue(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	value, err := gotell.TraceValue(ctx, tracedValue)
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "tracedValue", spans[0].Name())
+	assert.Equal(t, codes.Ok, spans[0].Status().Code)
+}
+
+func TestTracePanic(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = gotell.Trace(ctx, tracedPanic)
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+func TestTraceSeq(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := newRecordedContext(t)
+
+	seq := gotell.TraceSeq(ctx, tracedSeq)
+
+	assert.Empty(t, recorder.Ended())
+
+	for value := range seq {
+		if value == 2 {
+			break
+		}
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, slices.Collect(seq))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "tracedSeq", spans[0].Name())
+}
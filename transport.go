@@ -0,0 +1,168 @@
+package gotell
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTransport creates an instrumented HTTP transport on top of base, or of
+// http.DefaultTransport if nil. It combines WithTracing and WithMetrics.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return WithTracing(WithMetrics(base))
+}
+
+// WithTracing instruments another HTTP round tripper with a client span per
+// request. It injects the span context into the outgoing headers using the
+// global propagator, and adds the connection lifecycle events from
+// net/http/httptrace, such as DNS lookups and TLS handshakes, to the span.
+//
+// The span ends once the response body is read or closed, as the request only
+// completes then.
+func WithTracing(next http.RoundTripper) http.RoundTripper {
+	return wrapRoundTripper(next, func(req *http.Request) (*http.Response, error) {
+		// we just pretend we didn't see anything...
+		if req == nil {
+			//nolint:wrapcheck // passthrough
+			return next.RoundTrip(req)
+		}
+
+		ctx := req.Context()
+
+		ctx, span := tracerStartInfo(
+			ctx,
+			Tracer(ctx),
+			SpanNameFormatter("", req),
+			GetFunctionInfo(0),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(clientSpanAttributes(req)...),
+			trace.WithAttributes(RequestHeaderAttributes(req.Header, capturedHeadersFromEnv(CaptureClientRequestHeadersEnv)...)...),
+		)
+
+		ctx = httptrace.WithClientTrace(ctx, clientTraceEvents(span))
+
+		// round trippers must not modify the original request
+		req = req.Clone(ctx)
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			span.SetAttributes(errorTypeKey.String(ErrorType(err)))
+			span.Assert(err)
+			span.End()
+
+			//nolint:wrapcheck // passthrough
+			return res, err
+		}
+
+		span.SetAttributes(ResponseAttributes(res)...)
+		span.SetAttributes(ResponseHeaderAttributes(res.Header, capturedHeadersFromEnv(CaptureClientResponseHeadersEnv)...)...)
+
+		statusCode, statusText := SpanStatusForKind(trace.SpanKindClient, res.StatusCode)
+		if statusCode == codes.Error {
+			span.SetAttributes(errorTypeKey.String(strconv.Itoa(res.StatusCode)))
+		}
+
+		span.SetStatus(statusCode, statusText)
+
+		onBodyDone(res, func(err error) {
+			if err != nil {
+				span.Assert(err)
+			}
+
+			span.End()
+		})
+
+		return res, nil
+	})
+}
+
+// clientSpanAttributes returns the outgoing request attributes for spans,
+// which include the full URL on top of the ClientRequestAttributes.
+func clientSpanAttributes(req *http.Request) []attribute.KeyValue {
+	mode := httpSemconv.get()
+	attrs := ClientRequestAttributes(req)
+
+	// the URL must not include credentials
+	if mode.emitsNew() {
+		attrs = append(attrs, attribute.String("url.full", req.URL.Redacted()))
+	}
+
+	if mode.emitsOld() {
+		attrs = append(attrs, attribute.String("http.url", req.URL.Redacted()))
+	}
+
+	return attrs
+}
+
+// clientTraceEvents adds the connection lifecycle events to the span.
+func clientTraceEvents(span Span) *httptrace.ClientTrace {
+	event := func(name string, attrs ...attribute.KeyValue) {
+		span.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+
+	errorEvent := func(name string, err error, attrs ...attribute.KeyValue) {
+		if err != nil {
+			attrs = append(attrs, errorTypeKey.String(ErrorType(err)), attribute.String("exception.message", err.Error()))
+		}
+
+		event(name, attrs...)
+	}
+
+	//nolint:exhaustruct // only the connection lifecycle matters
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			event("http.get_conn", attribute.String("server.address", hostPort))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			event("http.got_conn",
+				attribute.Bool("http.conn.reused", info.Reused),
+				attribute.Bool("http.conn.was_idle", info.WasIdle),
+			)
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			event("http.dns.start", attribute.String("server.address", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			errorEvent("http.dns.done", info.Err, attribute.Int("http.dns.addresses", len(info.Addrs)))
+		},
+		ConnectStart: func(network, addr string) {
+			event("http.connect.start",
+				attribute.String("network.transport", network),
+				attribute.String("network.peer.address", addr),
+			)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			errorEvent("http.connect.done", err,
+				attribute.String("network.transport", network),
+				attribute.String("network.peer.address", addr),
+			)
+		},
+		TLSHandshakeStart: func() {
+			event("http.tls.start")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			errorEvent("http.tls.done", err,
+				attribute.String("tls.protocol.version", tls.VersionName(state.Version)),
+				attribute.Bool("tls.resumed", state.DidResume),
+			)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			errorEvent("http.wrote_request", info.Err)
+		},
+		GotFirstResponseByte: func() {
+			event("http.first_response_byte")
+		},
+	}
+}
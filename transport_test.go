@@ -0,0 +1,276 @@
+package gotell_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wwmoraes/gotell"
+)
+
+//nolint:paralleltest // sets the global propagator
+func TestWithTracing(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantStatus codes.Code
+	}{
+		{"ok", http.StatusOK, codes.Ok},
+		{"not found", http.StatusNotFound, codes.Error},
+		{"server error", http.StatusBadGateway, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traceparent string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("Traceparent")
+
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("body"))
+			}))
+			t.Cleanup(server.Close)
+
+			ctx, recorder := newRecordedContext(t)
+
+			client := &http.Client{Transport: gotell.WithTracing(server.Client().Transport)}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users/42", nil)
+			require.NoError(t, err)
+
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			assert.Empty(t, req.Header.Get("Traceparent"), "original request modified")
+			assert.Empty(t, recorder.Ended(), "span ended before the body was read")
+
+			_, err = io.Copy(io.Discard, res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+
+			span := spans[0]
+			assert.Equal(t, http.MethodGet, span.Name())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, tt.wantStatus, span.Status().Code)
+			assert.Contains(t, traceparent, span.SpanContext().SpanID().String())
+
+			events := make([]string, 0, len(span.Events()))
+			for _, event := range span.Events() {
+				events = append(events, event.Name)
+			}
+
+			assert.Contains(t, events, "http.connect.done")
+			assert.Contains(t, events, "http.first_response_byte")
+		})
+	}
+}
+
+//nolint:paralleltest // uses the global meter provider
+func TestWithMetricsConnections(t *testing.T) {
+	globalMetricReader()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+
+		_, _ = w.Write([]byte("body"))
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	//nolint:exhaustruct // defaults are fine
+	client := &http.Client{Transport: gotell.WithMetrics(gotell.TrackConnections(&http.Transport{}))}
+	serverPort := attribute.Int("server.port", port)
+	active := attribute.String("http.connection.state", "active")
+	idle := attribute.String("http.connection.state", "idle")
+
+	get := func(path string) {
+		res, err := client.Get(server.URL + path)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = io.Copy(io.Discard, res.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+	}
+
+	get("/")
+	assert.Equal(t, int64(0), int64SumValue(t, "http.client.open_connections", serverPort, active))
+	assert.Equal(t, int64(1), int64SumValue(t, "http.client.open_connections", serverPort, idle))
+	assert.Equal(t, int64(0), int64SumValue(t, "http.client.active_requests", serverPort))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		get("/slow")
+	}()
+
+	assert.Eventually(t, func() bool {
+		return int64SumValue(t, "http.client.active_requests", serverPort) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), int64SumValue(t, "http.client.open_connections", serverPort, active))
+	assert.Equal(t, int64(0), int64SumValue(t, "http.client.open_connections", serverPort, idle))
+
+	close(release)
+	<-done
+
+	assert.Equal(t, int64(0), int64SumValue(t, "http.client.active_requests", serverPort))
+	assert.Equal(t, int64(1), int64SumValue(t, "http.client.open_connections", serverPort, idle))
+	assert.Equal(t, uint64(0), float64HistogramCount(t, "http.client.connection.duration", serverPort))
+
+	client.CloseIdleConnections()
+
+	assert.Equal(t, int64(0), int64SumValue(t, "http.client.open_connections", serverPort))
+	assert.Equal(t, uint64(1), float64HistogramCount(t, "http.client.connection.duration", serverPort))
+}
+
+func TestTrackConnectionsHTTP2(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		transport *http.Transport
+		want      bool
+	}{
+		//nolint:exhaustruct // defaults are fine
+		{"default", &http.Transport{}, true},
+		//nolint:exhaustruct // defaults are fine
+		{"forced", &http.Transport{TLSClientConfig: &tls.Config{}, ForceAttemptHTTP2: true}, true},
+		//nolint:exhaustruct // defaults are fine
+		{"tls config", &http.Transport{TLSClientConfig: &tls.Config{}}, false},
+		//nolint:exhaustruct // defaults are fine
+		{"dialer", &http.Transport{DialContext: (&net.Dialer{}).DialContext}, false},
+		//nolint:exhaustruct // defaults are fine
+		{"next protos", &http.Transport{TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := gotell.TrackConnections(tt.transport)
+
+			assert.Same(t, tt.transport, transport)
+			assert.Equal(t, tt.want, transport.ForceAttemptHTTP2)
+			assert.NotNil(t, transport.DialContext)
+		})
+	}
+}
+
+//nolint:paralleltest // reads the global meter provider
+func TestTrackConnectionsTLS(t *testing.T) {
+	globalMetricReader()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	serverPort := attribute.Int("server.port", port)
+
+	//nolint:forcetypeassert // httptest always uses a *http.Transport
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	tests := []struct {
+		name      string
+		transport *http.Transport
+		wantProto int
+		wantConns int64
+	}{
+		{
+			name:      "forced http2",
+			transport: &http.Transport{TLSClientConfig: tlsConfig.Clone(), ForceAttemptHTTP2: true},
+			wantProto: 2,
+			wantConns: 1,
+		},
+		{
+			name:      "http1",
+			transport: &http.Transport{TLSClientConfig: tlsConfig.Clone()},
+			wantProto: 1,
+			wantConns: 1,
+		},
+		{
+			name: "tls dialer",
+			transport: &http.Transport{
+				ForceAttemptHTTP2: true,
+				DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					//nolint:exhaustruct // defaults are fine
+					dialer := &tls.Dialer{Config: tlsConfig.Clone()}
+					dialer.Config.NextProtos = []string{"h2"}
+
+					return dialer.DialContext(ctx, network, addr)
+				},
+			},
+			wantProto: 2,
+			wantConns: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := int64SumValue(t, "http.client.open_connections", serverPort)
+
+			//nolint:exhaustruct // defaults are fine
+			client := &http.Client{Transport: gotell.WithMetrics(gotell.TrackConnections(tt.transport))}
+			t.Cleanup(client.CloseIdleConnections)
+
+			res, err := client.Get(server.URL)
+			require.NoError(t, err)
+
+			_, err = io.Copy(io.Discard, res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tt.wantProto, res.ProtoMajor)
+			assert.NotNil(t, res.TLS)
+			assert.Equal(t, before+tt.wantConns, int64SumValue(t, "http.client.open_connections", serverPort))
+
+			client.CloseIdleConnections()
+
+			assert.Eventually(t, func() bool {
+				return int64SumValue(t, "http.client.open_connections", serverPort) == before
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gotell
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+	"slices"
+)
+
+const (
+	traceparentHeader = "Traceparent"
+
+	traceContextRejectionKey = "gotell.trace_context.rejection"
+	traceContextMalformed    = "malformed"
+	traceContextUntrusted    = "untrusted"
+)
+
+// TrustPolicy reports whether the remote span context of a request can be the
+// parent of its server span. Untrusted requests start a new trace linked to the
+// remote one instead.
+type TrustPolicy func(req *http.Request) bool
+
+// TrustAlways trusts the remote span context of all requests.
+func TrustAlways() TrustPolicy {
+	return func(*http.Request) bool {
+		return true
+	}
+}
+
+// TrustNever distrusts the remote span context of all requests. Use it on
+// endpoints exposed to the internet.
+func TrustNever() TrustPolicy {
+	return func(*http.Request) bool {
+		return false
+	}
+}
+
+// TrustPrefixes trusts requests whose remote address is within any of the
+// prefixes, such as the ones of internal networks.
+//
+// The remote address is the direct peer. Forwarding headers are ignored, as
+// clients can forge them.
+func TrustPrefixes(prefixes ...netip.Prefix) TrustPolicy {
+	return func(req *http.Request) bool {
+		addr, ok := remoteAddr(req)
+		if !ok {
+			return false
+		}
+
+		return slices.ContainsFunc(prefixes, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+	}
+}
+
+// TrustHeader trusts requests with the header set to the value, such as one a
+// gateway adds after authenticating the client.
+//
+// It trusts no request if the value is empty, as any request without the header
+// would match it otherwise.
+func TrustHeader(name, value string) TrustPolicy {
+	if value == "" {
+		return TrustNever()
+	}
+
+	return func(req *http.Request) bool {
+		got := req.Header.Values(name)
+		if len(got) != 1 {
+			return false
+		}
+
+		return subtle.ConstantTimeCompare([]byte(got[0]), []byte(value)) == 1
+	}
+}
+
+// TrustAny trusts requests that any of the policies trusts.
+func TrustAny(policies ...TrustPolicy) TrustPolicy {
+	return func(req *http.Request) bool {
+		for _, policy := range policies {
+			if policy(req) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func remoteAddr(req *http.Request) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(req.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(req.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
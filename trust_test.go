@@ -0,0 +1,62 @@
+package gotell_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wwmoraes/gotell"
+)
+
+func TestTrustPolicy(t *testing.T) {
+	t.Parallel()
+
+	internal := gotell.TrustPrefixes(
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	)
+	gateway := gotell.TrustHeader("X-Gateway-Token", "secret")
+	empty := gotell.TrustHeader("X-Gateway-Token", "")
+
+	tests := []struct {
+		name       string
+		policy     gotell.TrustPolicy
+		remoteAddr string
+		headers    http.Header
+		want       bool
+	}{
+		{"always", gotell.TrustAlways(), "203.0.113.1:1234", nil, true},
+		{"never", gotell.TrustNever(), "10.0.0.1:1234", nil, false},
+		{"internal v4", internal, "10.1.2.3:1234", nil, true},
+		{"internal v6", internal, "[fd00::1]:1234", nil, true},
+		{"mapped v4", internal, "[::ffff:10.1.2.3]:1234", nil, true},
+		{"external", internal, "203.0.113.1:1234", nil, false},
+		{"no port", internal, "10.1.2.3", nil, true},
+		{"invalid address", internal, "pipe", nil, false},
+		{"header", gateway, "203.0.113.1:1234", http.Header{"X-Gateway-Token": {"secret"}}, true},
+		{"wrong header", gateway, "203.0.113.1:1234", http.Header{"X-Gateway-Token": {"guess"}}, false},
+		{"missing header", gateway, "203.0.113.1:1234", nil, false},
+		{"repeated header", gateway, "203.0.113.1:1234", http.Header{"X-Gateway-Token": {"secret", "guess"}}, false},
+		{"empty value", empty, "203.0.113.1:1234", nil, false},
+		{"empty value header", empty, "203.0.113.1:1234", http.Header{"X-Gateway-Token": {""}}, false},
+		{"any", gotell.TrustAny(gateway, internal), "10.1.2.3:1234", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for key, values := range tt.headers {
+				req.Header[key] = values
+			}
+
+			assert.Equal(t, tt.want, tt.policy(req))
+		})
+	}
+}